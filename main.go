@@ -1,15 +1,25 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 
 	"github.com/atharv24/target49server/udp_server"
 )
 
 func main() {
+	roomsPath := flag.String("rooms", udp_server.DEFAULT_ROOMS_PATH, "JSON file listing the rooms to host with their modes and maps")
+	flag.Parse()
+
 	port := 42069
 	broadcastDelayMs := 10
 	server := udp_server.NewServer(port, broadcastDelayMs)
+	if err := server.LoadMap(udp_server.DEFAULT_MAP_PATH); err != nil {
+		fmt.Println("Using default spawn area:", err.Error())
+	}
+	if err := server.LoadRooms(*roomsPath); err != nil {
+		fmt.Println("Error loading rooms:", err.Error())
+	}
 	err := server.Start()
 	if err != nil {
 		fmt.Println("Error listening:", err.Error())
@@ -0,0 +1,59 @@
+package udp_server
+
+import "math"
+
+// sanitizeActions drops unknown bits and combinations a player cant be in, sprinting ends when crouching or aiming
+func sanitizeActions(actions int) int {
+	actions &= ACTION_FLAG_CROUCH | ACTION_FLAG_SPRINT | ACTION_FLAG_AIM | ACTION_FLAG_RELOAD
+	if actions&(ACTION_FLAG_CROUCH|ACTION_FLAG_AIM) != 0 {
+		actions &^= ACTION_FLAG_SPRINT
+	}
+	return actions
+}
+
+func (c Config) maxSpeed(actions int) float64 {
+	switch {
+	case actions&ACTION_FLAG_SPRINT != 0:
+		return c.SprintSpeed
+	case actions&ACTION_FLAG_CROUCH != 0:
+		return c.CrouchSpeed
+	default:
+		return c.WalkSpeed
+	}
+}
+
+// speedCheckOrigin is where movement is measured from, the last accepted position at least
+// SPEED_CHECK_MIN_MS old, or the spawn when the player hasnt moved since
+func speedCheckOrigin(ps PlayerState) (Position, int64) {
+	if ps.SpeedCheckAt == 0 {
+		return ps.Position, ps.ReceivedAt
+	}
+	return ps.SpeedCheckFrom, ps.SpeedCheckAt
+}
+
+// checkSpeed rejects horizontal movement faster than the players stance allows, falling is not limited.
+// Time is measured on the server so clients cant stretch it. Updates that arrive bunched together after a lag
+// spike share one SPEED_CHECK_MIN_MS allowance measured from the same origin, so they arent mistaken for
+// teleports but cant each move a full allowance either
+func (pm *PlayerManager) checkSpeed(oldState PlayerState, newState PlayerState, receivedAt int64) bool {
+	from, fromAt := speedCheckOrigin(oldState)
+	if pm.config.WalkSpeed == 0 || fromAt == 0 {
+		return true
+	}
+	elapsedMs := receivedAt - fromAt
+	if elapsedMs < SPEED_CHECK_MIN_MS {
+		elapsedMs = SPEED_CHECK_MIN_MS
+	}
+	// either stance counts, the player may have started sprinting between updates
+	speed := math.Max(pm.config.maxSpeed(oldState.Actions), pm.config.maxSpeed(newState.Actions))
+	allowed := speed*float64(elapsedMs)/1000*SPEED_TOLERANCE + SPEED_SLACK
+
+	moved := newState.Position.Sub(from)
+	moved.y = 0
+	if moved.Length() <= allowed {
+		return true
+	}
+	logger.debug("Player %d moved %.2f in %dms, allowed %.2f, snapping back", oldState.ID, moved.Length(), elapsedMs, allowed)
+	pm.send(oldState.Addr, parser.EncodePlayerResetMessage(oldState))
+	return false
+}
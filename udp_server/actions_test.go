@@ -0,0 +1,94 @@
+package udp_server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSanitizeActions(t *testing.T) {
+	assert.Equal(t, ACTION_FLAG_SPRINT, sanitizeActions(ACTION_FLAG_SPRINT))
+	assert.Equal(t, ACTION_FLAG_CROUCH, sanitizeActions(ACTION_FLAG_CROUCH|ACTION_FLAG_SPRINT))
+	assert.Equal(t, ACTION_FLAG_AIM|ACTION_FLAG_RELOAD, sanitizeActions(ACTION_FLAG_AIM|ACTION_FLAG_RELOAD|ACTION_FLAG_SPRINT))
+	assert.Equal(t, 0, sanitizeActions(1<<10))
+}
+
+func TestCrouchingShrinksHitVolume(t *testing.T) {
+	standing := PlayerState{}
+	crouching := PlayerState{Actions: ACTION_FLAG_CROUCH}
+	assert.Equal(t, float32(PLAYER_HIT_HEIGHT), standing.HitBox().Max.y)
+	assert.Equal(t, float32(PLAYER_CROUCH_HEIGHT), crouching.HitBox().Max.y)
+
+	head, _ := ZoneBox(Position{}, HIT_ZONE_HEAD, ACTION_FLAG_CROUCH)
+	assert.Equal(t, float32(PLAYER_CROUCH_HEIGHT), head.Max.y)
+	assert.Less(t, eyeHeight(ACTION_FLAG_CROUCH), eyeHeight(0))
+}
+
+func TestRocketFliesOverCrouchingPlayer(t *testing.T) {
+	pm, _ := newMatchPlayerManager(t)
+	shooter, _ := pm.CreatePlayer(newTestAddr(1), "shooter", NO_TEAM)
+	victim, _ := pm.CreatePlayer(newTestAddr(2), "victim", NO_TEAM)
+	placePlayer(pm, shooter, Position{})
+	pm.UpdatePlayerState(victim.Addr.String(), PlayerState{Position: Position{z: 10}, Actions: ACTION_FLAG_CROUCH, LastUpdatedAt: time.Now().UnixMilli()})
+
+	fire := Fire{Weapon: WEAPON_ROCKET, Origin: Position{y: 1.5}, Direction: Position{z: 1}}
+	projectile, _ := pm.FireProjectile(shooter.Addr.String(), fire, 0)
+	pm.tickProjectiles(500)
+
+	_, ok := pm.entities.Get(projectile.ID)
+	assert.True(t, ok)
+	victimState, _ := pm.GetPlayerState(victim.Addr.String())
+	assert.Equal(t, MAX_HEALTH, victimState.Health)
+}
+
+// withSpeedChecks turns the movement speed checks back on
+func withSpeedChecks(config *Config) {
+	config.WalkSpeed = PLAYER_WALK_SPEED
+}
+
+func TestSprintingAllowsFasterMovement(t *testing.T) {
+	pm, packets := newMatchPlayerManager(t, withSpeedChecks)
+	player, _ := pm.CreatePlayer(newTestAddr(1), "player", NO_TEAM)
+	start := player.Position
+	step := start.Add(Position{x: 1.5})
+
+	now := time.Now().UnixMilli()
+	// measure from now so a slow test run doesnt widen the allowance
+	pm.modifyPlayerState(player.Addr.String(), func(ps *PlayerState) {
+		ps.ReceivedAt = now
+	})
+	err := pm.UpdatePlayerState(player.Addr.String(), PlayerState{Position: step, LastUpdatedAt: now})
+	assert.NotNil(t, err)
+	assert.True(t, hasPacket(*packets, PLAYER_RESET_MESSAGE))
+	playerState, _ := pm.GetPlayerState(player.Addr.String())
+	assert.Equal(t, start, playerState.Position)
+
+	err = pm.UpdatePlayerState(player.Addr.String(), PlayerState{Position: step, Actions: ACTION_FLAG_SPRINT, LastUpdatedAt: now})
+	assert.Nil(t, err)
+	playerState, _ = pm.GetPlayerState(player.Addr.String())
+	assert.Equal(t, step, playerState.Position)
+	assert.Equal(t, ACTION_FLAG_SPRINT, playerState.Actions)
+
+	// falling is not limited
+	err = pm.UpdatePlayerState(player.Addr.String(), PlayerState{Position: step.Add(Position{y: -5}), LastUpdatedAt: now})
+	assert.Nil(t, err)
+}
+
+func TestBunchedUpdatesShareOneAllowance(t *testing.T) {
+	pm, _ := newMatchPlayerManager(t, withSpeedChecks)
+	player, _ := pm.CreatePlayer(newTestAddr(1), "player", NO_TEAM)
+	start := player.Position
+	now := time.Now().UnixMilli()
+	pm.modifyPlayerState(player.Addr.String(), func(ps *PlayerState) {
+		ps.ReceivedAt = now
+	})
+
+	// 50 updates at once, each a step that would be fine on its own
+	for i := 1; i <= 50; i++ {
+		pm.UpdatePlayerState(player.Addr.String(), PlayerState{Position: start.Add(Position{x: float32(i)}), LastUpdatedAt: now})
+	}
+	playerState, _ := pm.GetPlayerState(player.Addr.String())
+	allowed := PLAYER_WALK_SPEED*SPEED_CHECK_MIN_MS/1000*SPEED_TOLERANCE + SPEED_SLACK
+	assert.LessOrEqual(t, float64(playerState.Position.Sub(start).Length()), allowed)
+}
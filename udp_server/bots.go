@@ -0,0 +1,212 @@
+package udp_server
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"net"
+	"sync/atomic"
+)
+
+// BotBrain is the component that marks a player entity as a server controlled bot
+type BotBrain struct {
+	TargetID    int
+	Destination Position
+	NextShotAt  int64
+	LastThinkAt int64
+}
+
+func (b BotBrain) ComponentType() string {
+	return COMPONENT_BOT
+}
+
+// bots get addresses on 0.0.0.0, which no real client can send from, so packets to them are dropped
+func newBotAddr(number int) *net.UDPAddr {
+	return &net.UDPAddr{IP: net.IPv4zero, Port: number}
+}
+
+func isBotAddr(addr *net.UDPAddr) bool {
+	return addr != nil && addr.IP.Equal(net.IPv4zero)
+}
+
+// lookAt is the orientation that faces from one point to another
+func lookAt(from Position, to Position) Orientation {
+	delta := to.Sub(from)
+	horizontal := math.Sqrt(float64(delta.x*delta.x + delta.z*delta.z))
+	yaw := math.Atan2(float64(delta.x), float64(delta.z)) * 180 / math.Pi
+	if yaw < 0 {
+		yaw += 360
+	}
+	pitch := math.Atan2(float64(delta.y), horizontal) * 180 / math.Pi
+	return Orientation{Yaw: float32(yaw), Pitch: float32(pitch)}
+}
+
+func (pm *PlayerManager) botEntities() []Entity {
+	bots := []Entity{}
+	for _, entity := range pm.entities.Query(ENTITY_TYPE_PLAYER) {
+		if _, ok := GetComponent[BotBrain](entity); ok {
+			bots = append(bots, entity)
+		}
+	}
+	return bots
+}
+
+func (pm *PlayerManager) tickBots(now int64) {
+	pm.balanceBots(now)
+	for _, entity := range pm.botEntities() {
+		pm.tickBot(entity, now)
+	}
+}
+
+// balanceBots adds or removes one bot per tick until humans and bots add up to the target,
+// so bots make room as people join and come back as they leave. An empty server has no bots,
+// otherwise they would keep playing matches with nobody watching
+func (pm *PlayerManager) balanceBots(now int64) {
+	bots := pm.botEntities()
+	humans := len(pm.entities.Query(ENTITY_TYPE_PLAYER)) - len(bots)
+	wanted := pm.config.BotTargetPlayers - humans
+	if wanted < 0 || humans == 0 {
+		wanted = 0
+	}
+	switch {
+	case len(bots) < wanted:
+		pm.AddBot(now)
+	case len(bots) > wanted:
+		// the newest bot goes first
+		pm.RemoveBot(bots[len(bots)-1].ID)
+	}
+}
+
+// AddBot logs a bot in through the same path as a client and announces it to everyone
+func (pm *PlayerManager) AddBot(now int64) (PlayerState, error) {
+	number := int(atomic.AddInt32(&pm.botCount, 1))
+	botState, err := pm.CreatePlayer(newBotAddr(number), fmt.Sprintf("%s%d", BOT_NAME_PREFIX, number), NO_TEAM)
+	if err != nil {
+		return PlayerState{}, err
+	}
+	if _, err := pm.entities.Modify(botState.ID, func(e *Entity) {
+		e.SetComponent(BotBrain{LastThinkAt: now})
+	}); err != nil {
+		return PlayerState{}, err
+	}
+	pm.broadcast(parser.EncodePlayerStateForInit(botState))
+	logger.info("Player %d joined as a bot: %s", botState.ID, botState.Name)
+	return botState, nil
+}
+
+func (pm *PlayerManager) RemoveBot(playerID int) {
+	botState, err := pm.GetPlayerStateByID(playerID)
+	if err != nil {
+		logger.warn(err.Error())
+		return
+	}
+	if _, err := pm.RemovePlayer(botState.Addr.String()); err != nil {
+		logger.warn(err.Error())
+		return
+	}
+	pm.broadcast(parser.EncodePlayerLeaveMessage(botState.ID))
+	pm.BroadcastScores()
+	logger.info("Player %d left as a bot: %s", botState.ID, botState.Name)
+}
+
+// tickBot walks the bot between spawn points, faces the closest enemy it can see
+// and shoots at it once it had time to react, with hits going through the normal shot rules
+func (pm *PlayerManager) tickBot(entity Entity, now int64) {
+	brain, _ := GetComponent[BotBrain](entity)
+	botState, err := playerStateFromEntity(entity)
+	if err != nil || botState.Health <= 0 || now-botState.RespawnAt < RESPAWN_IDLE_DELAY_MS {
+		return
+	}
+	seconds := float32(now-brain.LastThinkAt) / 1000
+	if seconds < 0 || seconds > BOT_MAX_STEP_MS/1000.0 {
+		seconds = BOT_MAX_STEP_MS / 1000.0
+	}
+	brain.LastThinkAt = now
+
+	target, hasTarget := pm.botTarget(botState)
+	switch {
+	case !hasTarget:
+		brain.TargetID = 0
+	case target.ID != brain.TargetID:
+		brain.TargetID = target.ID
+		brain.NextShotAt = now + pm.config.BotReactionMs
+	}
+
+	// bots walk on the level they spawned on, maps with stairs need waypoints
+	level := pm.currentLevel()
+	if brain.Destination == (Position{}) || botState.Position.DistanceTo(brain.Destination) < BOT_ARRIVE_DISTANCE {
+		brain.Destination = level.spawnManager.RandomPosition()
+	}
+	heading := brain.Destination.Sub(botState.Position)
+	heading.y = 0
+	velocity := heading.Normalized().Scale(BOT_WALK_SPEED)
+	next := botState.Position.Add(velocity.Scale(seconds))
+	if level.geometry.Overlaps(playerBoxAt(next, 0)) || !level.world.InBounds(next) {
+		// blocked, try somewhere else next tick
+		brain.Destination = Position{}
+		next, velocity = botState.Position, Position{}
+	}
+	rotation := lookAt(botState.Position, botState.Position.Add(heading))
+	if hasTarget {
+		rotation = lookAt(botState.Position.Add(Position{y: eyeHeight(0)}), target.Center())
+	}
+
+	movedState, err := pm.modifyPlayerState(botState.Addr.String(), func(ps *PlayerState) {
+		ps.Position = next
+		ps.Velocity = velocity
+		ps.Rotation = rotation
+		ps.LastUpdatedAt = now
+		ps.ReceivedAt = now
+	})
+	pm.history.Record(botState.ID, next, rotation, 0, now, now)
+
+	if hasTarget && now >= brain.NextShotAt && err == nil {
+		brain.NextShotAt = now + BOT_FIRE_INTERVAL_MS
+		pm.botShoot(movedState, target, now)
+	}
+	pm.entities.Modify(entity.ID, func(e *Entity) {
+		e.SetComponent(brain)
+	})
+}
+
+// botTarget is the closest living enemy the bot has a clear line to
+func (pm *PlayerManager) botTarget(botState PlayerState) (PlayerState, bool) {
+	eyes := botState.Position.Add(Position{y: eyeHeight(botState.Actions)})
+	var target PlayerState
+	closest := BOT_SIGHT_RANGE
+	found := false
+	geometry := pm.currentLevel().geometry
+	for _, ps := range pm.GetAllPlayerStates(nil) {
+		if ps.ID == botState.ID || ps.Health <= 0 || !botState.IsEnemy(ps) {
+			continue
+		}
+		distance := eyes.DistanceTo(ps.Center())
+		if distance > closest || !geometry.LineOfSight(eyes, ps.Center()) {
+			continue
+		}
+		target, closest, found = ps, distance, true
+	}
+	return target, found
+}
+
+func (pm *PlayerManager) botShoot(botState PlayerState, target PlayerState, now int64) {
+	if rand.Float64() >= pm.config.BotAccuracy {
+		return
+	}
+	zone := HIT_ZONE_BODY
+	if rand.Float64() < BOT_HEADSHOT_CHANCE {
+		zone = HIT_ZONE_HEAD
+	}
+	// hits are checked against the aim, so the bot looks at the zone it means to hit
+	box, _ := ZoneBox(target.Position, zone, target.Actions)
+	rotation := lookAt(botState.Position.Add(Position{y: eyeHeight(botState.Actions)}), box.Center())
+	pm.modifyPlayerState(botState.Addr.String(), func(ps *PlayerState) {
+		ps.Rotation = rotation
+	})
+	pm.history.Record(botState.ID, botState.Position, rotation, botState.Actions, now, now)
+	shot := Shot{HitPlayerID: target.ID, LastUpdatedAt: now, Weapon: WEAPON_RIFLE, Zone: zone}
+	if addr := pm.HandlePlayerShot(shot, botState.Addr); addr != nil {
+		pm.NotifyRespawn(addr)
+		pm.BroadcastScores()
+	}
+}
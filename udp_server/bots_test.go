@@ -0,0 +1,112 @@
+package udp_server
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// withPerfectBots makes bots fire on the first tick and never miss
+func withPerfectBots(config *Config) {
+	config.BotAccuracy = 1
+	config.BotReactionMs = 0
+}
+
+func TestBotsFillAndMakeRoom(t *testing.T) {
+	pm, _ := newMatchPlayerManager(t, func(config *Config) {
+		config.BotTargetPlayers = 3
+	})
+	pm.CreatePlayer(newTestAddr(1), "human", NO_TEAM)
+
+	now := time.Now().UnixMilli()
+	for i := 0; i < 5; i++ {
+		pm.tickBots(now)
+	}
+	assert.Len(t, pm.botEntities(), 2)
+	assert.Len(t, pm.GetAllPlayerStates(nil), 3)
+
+	pm.CreatePlayer(newTestAddr(2), "human", NO_TEAM)
+	pm.tickBots(now)
+	assert.Len(t, pm.botEntities(), 1)
+	assert.Len(t, pm.GetAllPlayerStates(nil), 3)
+}
+
+func TestBotsLeaveAnEmptyServer(t *testing.T) {
+	pm, _ := newMatchPlayerManager(t, func(config *Config) {
+		config.BotTargetPlayers = 3
+	})
+	now := time.Now().UnixMilli()
+	pm.tickBots(now)
+	assert.Empty(t, pm.botEntities())
+
+	human, _ := pm.CreatePlayer(newTestAddr(1), "human", NO_TEAM)
+	pm.tickBots(now)
+	assert.Len(t, pm.botEntities(), 1)
+
+	pm.RemovePlayer(human.Addr.String())
+	pm.tickBots(now)
+	assert.Empty(t, pm.botEntities())
+}
+
+func TestBotShootsVisibleEnemy(t *testing.T) {
+	pm, _ := newMatchPlayerManager(t, withPerfectBots)
+	human, _ := pm.CreatePlayer(newTestAddr(1), "human", NO_TEAM)
+	placePlayer(pm, human, Position{})
+
+	now := time.Now().UnixMilli()
+	bot, err := pm.AddBot(now)
+	assert.Nil(t, err)
+	pm.modifyPlayerState(bot.Addr.String(), func(ps *PlayerState) {
+		ps.Position = Position{z: 5}
+	})
+
+	entity, _ := pm.entities.Get(bot.ID)
+	pm.tickBot(entity, now)
+	humanState, _ := pm.GetPlayerState(human.Addr.String())
+	assert.Less(t, humanState.Health, MAX_HEALTH)
+
+	entity, _ = pm.entities.Get(bot.ID)
+	brain, _ := GetComponent[BotBrain](entity)
+	assert.Equal(t, human.ID, brain.TargetID)
+	assert.Equal(t, now+BOT_FIRE_INTERVAL_MS, brain.NextShotAt)
+}
+
+func TestBotIgnoresHiddenEnemy(t *testing.T) {
+	pm, _ := newMatchPlayerManager(t, withPerfectBots)
+	pm.currentLevel().geometry = newWallGeometry()
+	human, _ := pm.CreatePlayer(newTestAddr(1), "human", NO_TEAM)
+	placePlayer(pm, human, Position{})
+
+	now := time.Now().UnixMilli()
+	bot, _ := pm.AddBot(now)
+	pm.modifyPlayerState(bot.Addr.String(), func(ps *PlayerState) {
+		ps.Position = Position{z: 10}
+	})
+	entity, _ := pm.entities.Get(bot.ID)
+	pm.tickBot(entity, now)
+
+	humanState, _ := pm.GetPlayerState(human.Addr.String())
+	assert.Equal(t, MAX_HEALTH, humanState.Health)
+}
+
+func TestPacketsToBotsAreDropped(t *testing.T) {
+	pm := NewPlayerManager()
+	sentTo := []*net.UDPAddr{}
+	pm.SetPacketSender(func(addr *net.UDPAddr, packet string) {
+		sentTo = append(sentTo, addr)
+	})
+	pm.AddBot(time.Now().UnixMilli())
+	pm.broadcastReliable(parser.EncodePlayerLeaveMessage(1))
+	pm.BroadcastScores()
+	assert.Empty(t, sentTo)
+}
+
+func TestBotsAreOffByDefault(t *testing.T) {
+	pm := NewPlayerManager()
+	pm.SetPacketSender(func(addr *net.UDPAddr, packet string) {})
+	pm.CreatePlayer(newTestAddr(1), "human", NO_TEAM)
+	pm.tickBots(time.Now().UnixMilli())
+	assert.Empty(t, pm.botEntities())
+}
@@ -0,0 +1,199 @@
+package udp_server
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+type ChatMessage struct {
+	SenderID int
+	Team     int // senders team, decides who sees team chat from the history
+	Scope    string
+	TargetID int
+	Text     string
+}
+
+func (cm ChatMessage) String() string {
+	return fmt.Sprintf("%d:%s:%d:%s", cm.SenderID, cm.Scope, cm.TargetID, cm.Text)
+}
+
+// ChatManager keeps the chat history, rate limits and who muted whom
+type ChatManager struct {
+	mu      sync.Mutex
+	history []ChatMessage
+	sentAt  map[int][]int64      // sender ID to the times of their recent messages
+	mutes   map[int]map[int]bool // player ID to the senders they muted
+}
+
+func NewChatManager() *ChatManager {
+	return &ChatManager{
+		sentAt: make(map[int][]int64),
+		mutes:  make(map[int]map[int]bool),
+	}
+}
+
+// allow records a message from the sender unless they already sent limit messages in the window
+func (cm *ChatManager) allow(senderID int, now int64, limit int, windowMs int64) bool {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	recent := []int64{}
+	for _, at := range cm.sentAt[senderID] {
+		if now-at < windowMs {
+			recent = append(recent, at)
+		}
+	}
+	if limit > 0 && len(recent) >= limit {
+		cm.sentAt[senderID] = recent
+		return false
+	}
+	cm.sentAt[senderID] = append(recent, now)
+	return true
+}
+
+// remember adds to the history late joiners get, whispers are never kept
+func (cm *ChatManager) remember(message ChatMessage) {
+	if message.Scope == CHAT_SCOPE_WHISPER {
+		return
+	}
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	cm.history = append(cm.history, message)
+	if len(cm.history) > CHAT_HISTORY_SIZE {
+		cm.history = cm.history[len(cm.history)-CHAT_HISTORY_SIZE:]
+	}
+}
+
+// History returns the kept messages a member of the given team may read, oldest first
+func (cm *ChatManager) History(team int) []ChatMessage {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	history := []ChatMessage{}
+	for _, message := range cm.history {
+		if message.Scope == CHAT_SCOPE_ALL || (team != NO_TEAM && message.Team == team) {
+			history = append(history, message)
+		}
+	}
+	return history
+}
+
+func (cm *ChatManager) SetMuted(playerID int, senderID int, muted bool) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	if !muted {
+		delete(cm.mutes[playerID], senderID)
+		return
+	}
+	if cm.mutes[playerID] == nil {
+		cm.mutes[playerID] = make(map[int]bool)
+	}
+	cm.mutes[playerID][senderID] = true
+}
+
+func (cm *ChatManager) IsMuted(playerID int, senderID int) bool {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	return cm.mutes[playerID][senderID]
+}
+
+// Forget drops the rate limit and mute list of a player who left
+func (cm *ChatManager) Forget(playerID int) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+	delete(cm.sentAt, playerID)
+	delete(cm.mutes, playerID)
+}
+
+// sanitizeChat removes what would break the packet layout and cuts the text to maxLength characters
+func sanitizeChat(text string, maxLength int) string {
+	text = strings.Map(func(r rune) rune {
+		if r == ';' || r == '\n' || r == '\r' {
+			return ' '
+		}
+		return r
+	}, text)
+	text = strings.TrimSpace(text)
+	if runes := []rune(text); len(runes) > maxLength {
+		text = strings.TrimSpace(string(runes[:maxLength]))
+	}
+	return text
+}
+
+// SendChat delivers a chat message from a player to everyone in its scope who hasnt muted them.
+// All chat also reaches spectators, whispers are echoed back to the sender
+func (pm *PlayerManager) SendChat(senderAddrStr string, message ChatMessage, now int64) error {
+	sender, err := pm.GetPlayerState(senderAddrStr)
+	if err != nil {
+		return err
+	}
+	message.SenderID = sender.ID
+	message.Team = sender.Team
+	if message.Text = sanitizeChat(message.Text, pm.config.ChatMaxLength); message.Text == "" {
+		return fmt.Errorf("empty chat from Player %d", sender.ID)
+	}
+
+	recipients := []PlayerState{}
+	switch message.Scope {
+	case CHAT_SCOPE_ALL:
+		message.TargetID = 0
+	case CHAT_SCOPE_TEAM:
+		if sender.Team == NO_TEAM {
+			return fmt.Errorf("Player %d has no team to chat with", sender.ID)
+		}
+		message.TargetID = 0
+		for _, ps := range pm.GetAllPlayerStates(nil) {
+			if ps.Team == sender.Team {
+				recipients = append(recipients, ps)
+			}
+		}
+	case CHAT_SCOPE_WHISPER:
+		target, err := pm.GetPlayerStateByID(message.TargetID)
+		if err != nil {
+			return err
+		}
+		recipients = append(recipients, sender)
+		if target.ID != sender.ID {
+			recipients = append(recipients, target)
+		}
+	default:
+		return fmt.Errorf("unknown chat scope %s", message.Scope)
+	}
+	if !pm.chat.allow(sender.ID, now, pm.config.ChatRateLimit, pm.config.ChatRateWindowMs) {
+		return fmt.Errorf("Player %d is sending chat too fast", sender.ID)
+	}
+
+	pm.chat.remember(message)
+	packet := parser.EncodeChatMessage(message)
+	if message.Scope == CHAT_SCOPE_ALL {
+		for _, addr := range pm.GetRecipientAddrs() {
+			if ps, err := pm.GetPlayerState(addr.String()); err == nil && pm.chat.IsMuted(ps.ID, sender.ID) {
+				continue
+			}
+			pm.sendReliable(addr, packet, now)
+		}
+		return nil
+	}
+	for _, ps := range recipients {
+		if !pm.chat.IsMuted(ps.ID, sender.ID) {
+			pm.sendReliable(ps.Addr, packet, now)
+		}
+	}
+	return nil
+}
+
+// SetMuted hides or shows chat from another player for the player at addrStr
+func (pm *PlayerManager) SetMuted(addrStr string, senderID int, muted bool) error {
+	ps, err := pm.GetPlayerState(addrStr)
+	if err != nil {
+		return err
+	}
+	if _, err := pm.GetPlayerStateByID(senderID); muted && err != nil {
+		return err
+	}
+	pm.chat.SetMuted(ps.ID, senderID, muted)
+	return nil
+}
+
+func (pm *PlayerManager) GetChatHistory(team int) []ChatMessage {
+	return pm.chat.History(team)
+}
@@ -0,0 +1,92 @@
+package udp_server
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChatScopes(t *testing.T) {
+	pm, packets := newMatchPlayerManager(t, withTeams)
+	red, _ := pm.CreatePlayer(newTestAddr(1), "red", 1)
+	redMate, _ := pm.CreatePlayer(newTestAddr(2), "redMate", 1)
+	blue, _ := pm.CreatePlayer(newTestAddr(3), "blue", 2)
+	now := time.Now().UnixMilli()
+
+	err := pm.SendChat(red.Addr.String(), ChatMessage{Scope: CHAT_SCOPE_TEAM, Text: "push mid"}, now)
+	assert.Nil(t, err)
+	assert.Len(t, *packets, 2)
+	assert.True(t, hasReliablePacket(*packets, "G;1:team:0:push mid"))
+
+	*packets = nil
+	err = pm.SendChat(blue.Addr.String(), ChatMessage{Scope: CHAT_SCOPE_WHISPER, TargetID: redMate.ID, Text: "hi"}, now)
+	assert.Nil(t, err)
+	assert.Len(t, *packets, 2)
+
+	*packets = nil
+	err = pm.SendChat(blue.Addr.String(), ChatMessage{Scope: CHAT_SCOPE_ALL, Text: "gg"}, now)
+	assert.Nil(t, err)
+	assert.Len(t, *packets, 3)
+
+	// whispers are not kept, team chat only goes to that team
+	assert.Len(t, pm.GetChatHistory(1), 2)
+	assert.Len(t, pm.GetChatHistory(2), 1)
+	assert.Len(t, pm.GetChatHistory(NO_TEAM), 1)
+}
+
+func TestChatIsSanitizedAndLimited(t *testing.T) {
+	pm, packets := newMatchPlayerManager(t, func(config *Config) {
+		config.ChatMaxLength = 5
+		config.ChatRateLimit = 2
+		config.ChatRateWindowMs = 1000
+	})
+	player, _ := pm.CreatePlayer(newTestAddr(1), "player", NO_TEAM)
+	addrStr := player.Addr.String()
+	now := time.Now().UnixMilli()
+
+	assert.Nil(t, pm.SendChat(addrStr, ChatMessage{Scope: CHAT_SCOPE_ALL, Text: "a;b\ncdefgh"}, now))
+	assert.True(t, hasReliablePacket(*packets, "G;1:all:0:a b c"))
+	assert.NotNil(t, pm.SendChat(addrStr, ChatMessage{Scope: CHAT_SCOPE_ALL, Text: "  "}, now))
+	assert.NotNil(t, pm.SendChat(addrStr, ChatMessage{Scope: CHAT_SCOPE_TEAM, Text: "no team"}, now))
+
+	assert.Nil(t, pm.SendChat(addrStr, ChatMessage{Scope: CHAT_SCOPE_ALL, Text: "two"}, now))
+	assert.NotNil(t, pm.SendChat(addrStr, ChatMessage{Scope: CHAT_SCOPE_ALL, Text: "three"}, now))
+	assert.Nil(t, pm.SendChat(addrStr, ChatMessage{Scope: CHAT_SCOPE_ALL, Text: "later"}, now+1000))
+}
+
+func TestMutedPlayerChatIsHidden(t *testing.T) {
+	pm, packets := newMatchPlayerManager(t)
+	loud, _ := pm.CreatePlayer(newTestAddr(1), "loud", NO_TEAM)
+	quiet, _ := pm.CreatePlayer(newTestAddr(2), "quiet", NO_TEAM)
+	pm.CreateSpectator(newTestAddr(3), "watcher")
+	now := time.Now().UnixMilli()
+
+	assert.Nil(t, pm.SetMuted(quiet.Addr.String(), loud.ID, true))
+	*packets = nil
+	pm.SendChat(loud.Addr.String(), ChatMessage{Scope: CHAT_SCOPE_ALL, Text: "spam"}, now)
+	assert.Len(t, *packets, 2)
+
+	assert.Nil(t, pm.SetMuted(quiet.Addr.String(), loud.ID, false))
+	*packets = nil
+	pm.SendChat(loud.Addr.String(), ChatMessage{Scope: CHAT_SCOPE_ALL, Text: "hello"}, now)
+	assert.Len(t, *packets, 3)
+}
+
+func TestChatHistoryIsCapped(t *testing.T) {
+	pm, _ := newMatchPlayerManager(t, func(config *Config) {
+		config.ChatRateLimit = 0
+	})
+	player, _ := pm.CreatePlayer(newTestAddr(1), "player", NO_TEAM)
+	now := time.Now().UnixMilli()
+	for i := 0; i < CHAT_HISTORY_SIZE+5; i++ {
+		pm.SendChat(player.Addr.String(), ChatMessage{Scope: CHAT_SCOPE_ALL, Text: strings.Repeat("a", i+1)}, now)
+	}
+	history := pm.GetChatHistory(NO_TEAM)
+	assert.Len(t, history, CHAT_HISTORY_SIZE)
+	assert.Equal(t, strings.Repeat("a", 6), history[0].Text)
+
+	packet := parser.EncodePlayerStatesForInit(player, nil, nil, history[:1])
+	assert.True(t, strings.HasSuffix(packet, ";@1:all:0:aaaaaa"))
+}
@@ -0,0 +1,178 @@
+package udp_server
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Config holds the tunable game rules, defaults come from constants.go
+type Config struct {
+	Mode              string
+	SpawnProtectionMs int64
+	// 0 for free for all, 2 to MAX_TEAMS for team modes
+	TeamCount    int
+	FriendlyFire bool
+	// match lifecycle, a zero time or score limit disables that limit
+	MinPlayersToStart int
+	WarmupMs          int64
+	TimeLimitMs       int64
+	ScoreLimit        int
+	PostMatchMs       int64
+	// the match starts early once LobbyReadyRatio of the human players are ready, 0 leaves it to the warmup countdown
+	LobbyReadyRatio float64
+	// damage needed within the window before a kill to earn an assist
+	AssistMinDamage int
+	AssistWindowMs  int64
+	// kills this close together count towards a multikill
+	MultiKillWindowMs int64
+	StreakRewards     []StreakReward
+	// health comes back RegenAmount at a time once a player avoids damage for RegenDelayMs, 0 amount disables it
+	RegenDelayMs    int64
+	RegenIntervalMs int64
+	RegenAmount     int
+	SpawnArmor      int
+	// horizontal speed limits in units per second for each stance, a zero WalkSpeed turns the checks off
+	WalkSpeed   float64
+	SprintSpeed float64
+	CrouchSpeed float64
+	// bots fill the server up to BotTargetPlayers and leave as humans join, 0 turns bots off
+	BotTargetPlayers int
+	BotAccuracy      float64 // chance each bot shot hits
+	BotReactionMs    int64   // delay before a bot fires at a new target
+	// longer chat is cut to ChatMaxLength characters, players can send ChatRateLimit messages per window, 0 turns the limit off
+	ChatMaxLength    int
+	ChatRateLimit    int
+	ChatRateWindowMs int64
+	// names are NameMinLength to NameMaxLength letters, digits and NameAllowedSymbols,
+	// names containing a blocklisted word in any case are refused
+	NameMinLength      int
+	NameMaxLength      int
+	NameAllowedSymbols string
+	NameBlocklist      []string
+	// votes pass once more than VotePassRatio of the human players agree, players wait VoteCooldownMs between votes they start
+	VotePassRatio  float64
+	VoteTimeoutMs  int64
+	VoteCooldownMs int64
+	MapDir         string
+	// MaxPlayers caps the human players, 0 for no cap. ReservedAdminSlots of them only admins can take,
+	// admins are recognized by their IP being in AdminHosts. Bots dont take slots
+	MaxPlayers         int
+	ReservedAdminSlots int
+	JoinQueueSize      int
+	AdminHosts         []string
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Mode:              GAME_MODE_FREE_FOR_ALL,
+		SpawnProtectionMs: SPAWN_PROTECTION_MS,
+		TeamCount:         0,
+		FriendlyFire:      false,
+		MinPlayersToStart: MATCH_MIN_PLAYERS,
+		WarmupMs:          MATCH_WARMUP_MS,
+		LobbyReadyRatio:   LOBBY_READY_RATIO,
+		TimeLimitMs:       MATCH_TIME_LIMIT_MS,
+		ScoreLimit:        MATCH_SCORE_LIMIT,
+		PostMatchMs:       MATCH_POST_MATCH_MS,
+		AssistMinDamage:   ASSIST_MIN_DAMAGE,
+		AssistWindowMs:    ASSIST_WINDOW_MS,
+		MultiKillWindowMs: MULTI_KILL_WINDOW_MS,
+		StreakRewards: []StreakReward{
+			{Streak: 3, BonusScore: 1},
+			{Streak: 5, Buff: BUFF_DOUBLE_DAMAGE, BuffDurationMs: 10 * 1000},
+			{Streak: 10, BonusScore: 3},
+		},
+		RegenDelayMs:       REGEN_DELAY_MS,
+		RegenIntervalMs:    REGEN_INTERVAL_MS,
+		RegenAmount:        REGEN_AMOUNT,
+		SpawnArmor:         0,
+		WalkSpeed:          PLAYER_WALK_SPEED,
+		SprintSpeed:        PLAYER_SPRINT_SPEED,
+		CrouchSpeed:        PLAYER_CROUCH_SPEED,
+		BotTargetPlayers:   BOT_TARGET_PLAYERS,
+		BotAccuracy:        BOT_ACCURACY,
+		BotReactionMs:      BOT_REACTION_MS,
+		ChatMaxLength:      CHAT_MAX_LENGTH,
+		ChatRateLimit:      CHAT_RATE_LIMIT,
+		ChatRateWindowMs:   CHAT_RATE_WINDOW_MS,
+		NameMinLength:      NAME_MIN_LENGTH,
+		NameMaxLength:      NAME_MAX_LENGTH,
+		NameAllowedSymbols: NAME_ALLOWED_SYMBOLS,
+		VotePassRatio:      VOTE_PASS_RATIO,
+		VoteTimeoutMs:      VOTE_TIMEOUT_MS,
+		VoteCooldownMs:     VOTE_COOLDOWN_MS,
+		MapDir:             MAP_DIR,
+		MaxPlayers:         MAX_PLAYERS,
+		ReservedAdminSlots: RESERVED_ADMIN_SLOTS,
+		JoinQueueSize:      JOIN_QUEUE_SIZE,
+	}
+}
+
+func (c Config) Validate() error {
+	switch c.Mode {
+	case GAME_MODE_FREE_FOR_ALL:
+		if c.TeamCount != 0 {
+			return fmt.Errorf("%s mode cant have teams", c.Mode)
+		}
+	case GAME_MODE_TEAM_DEATHMATCH:
+		if c.TeamCount < 2 || c.TeamCount > MAX_TEAMS {
+			return fmt.Errorf("team count must be between 2 and %d, got %d", MAX_TEAMS, c.TeamCount)
+		}
+	default:
+		return fmt.Errorf("unknown game mode %s", c.Mode)
+	}
+	if c.SpawnProtectionMs < 0 {
+		return fmt.Errorf("spawn protection cant be negative")
+	}
+	if c.WarmupMs < 0 || c.TimeLimitMs < 0 || c.PostMatchMs < 0 || c.ScoreLimit < 0 {
+		return fmt.Errorf("match durations and score limit cant be negative")
+	}
+	if c.LobbyReadyRatio < 0 || c.LobbyReadyRatio > 1 {
+		return fmt.Errorf("lobby ready ratio must be between 0 and 1")
+	}
+	if c.AssistMinDamage < 1 || c.AssistWindowMs < 0 {
+		return fmt.Errorf("assists need a positive damage threshold and a non negative window")
+	}
+	if c.MultiKillWindowMs < 0 {
+		return fmt.Errorf("multikill window cant be negative")
+	}
+	if c.RegenDelayMs < 0 || c.RegenIntervalMs <= 0 || c.RegenAmount < 0 {
+		return fmt.Errorf("regen needs a non negative delay and amount and a positive interval")
+	}
+	if c.SpawnArmor < 0 || c.SpawnArmor > MAX_ARMOR {
+		return fmt.Errorf("spawn armor must be between 0 and %d", MAX_ARMOR)
+	}
+	if c.WalkSpeed < 0 || (c.WalkSpeed > 0 && (c.SprintSpeed < c.WalkSpeed || c.CrouchSpeed <= 0)) {
+		return fmt.Errorf("speed limits must be positive with sprinting at least as fast as walking")
+	}
+	if c.BotTargetPlayers < 0 || c.BotAccuracy < 0 || c.BotAccuracy > 1 || c.BotReactionMs < 0 {
+		return fmt.Errorf("bots need a non negative target and reaction time and an accuracy between 0 and 1")
+	}
+	if c.ChatMaxLength < 1 || c.ChatRateLimit < 0 || c.ChatRateWindowMs < 0 {
+		return fmt.Errorf("chat needs a positive length limit and a non negative rate limit")
+	}
+	if c.NameMinLength < 1 || c.NameMaxLength < c.NameMinLength {
+		return fmt.Errorf("names need a minimum length of at least 1 and a maximum no shorter than it")
+	}
+	if strings.ContainsAny(c.NameAllowedSymbols, NAME_RESERVED_SYMBOLS) {
+		return fmt.Errorf("names cant contain the protocol separators %s", NAME_RESERVED_SYMBOLS)
+	}
+	if c.VotePassRatio < 0 || c.VotePassRatio >= 1 || c.VoteTimeoutMs <= 0 || c.VoteCooldownMs < 0 {
+		return fmt.Errorf("votes need a pass ratio of at least 0 and below 1, a positive timeout and a non negative cooldown")
+	}
+	if c.MaxPlayers < 0 || c.ReservedAdminSlots < 0 || c.JoinQueueSize < 0 {
+		return fmt.Errorf("player cap, reserved slots and queue size cant be negative")
+	}
+	if c.MaxPlayers > 0 && (c.ReservedAdminSlots >= c.MaxPlayers || c.BotTargetPlayers > c.MaxPlayers) {
+		return fmt.Errorf("reserved admin slots must leave room for players and bots cant fill more than the player cap")
+	}
+	for _, reward := range c.StreakRewards {
+		if reward.Streak < 1 {
+			return fmt.Errorf("streak rewards need a streak of at least 1")
+		}
+		if reward.Buff != "" && reward.Buff != BUFF_DOUBLE_DAMAGE {
+			return fmt.Errorf("unknown streak buff %s", reward.Buff)
+		}
+	}
+	return nil
+}
@@ -1,28 +1,388 @@
 package udp_server
 
 const (
-	// S;{POS}:{TIMESTAMP} from client, S;{ID}:{POS}:{TIMESTAMP};{ID}:{POS}:{TIMESTAMP} from server
+	// S;{POS}:{ROT}:{TIMESTAMP}:{VELOCITY}:{ANGULAR_VELOCITY}:{ACTIONS} from client,
+	// S;{ID}:{POS}:{ROT}:{HEALTH}:{TIMESTAMP}:{PROTECTED}:{TEAM}:{ARMOR}:{VELOCITY}:{ANGULAR_VELOCITY}:{ACTIONS};... from server,
+	// followed by non player entities as ;#{ENTITY_ID}:{TYPE}:{POS}:{ROT}:{COMPONENT_DATA}...
+	// ROT and ANGULAR_VELOCITY are {YAW},{PITCH} in degrees, ACTIONS is a bitfield of ACTION_FLAG_*,
+	// clients may send a bare yaw and leave out everything after the timestamp
 	PLAYER_STATE_MESSAGE = "S"
 
-	// H;{HIT_PLAYER_ID}
+	// H;{HIT_PLAYER_ID}:{TIMESTAMP}, H;{HIT_PLAYER_ID}:{TIMESTAMP}:{WEAPON} or H;{HIT_PLAYER_ID}:{TIMESTAMP}:{WEAPON}:{HIT_ZONE},
+	// the zone defaults to body and is checked against the aim in the shooters last S
 	PLAYER_SHOT_MESSAGE = "H"
 
-	// L;{NAME} from client
+	// F;{TIMESTAMP} from client whenever it fires, hit or miss,
+	// F;{TIMESTAMP}:{WEAPON}:{ORIGIN}:{DIRECTION} for projectile weapons
+	PLAYER_FIRE_MESSAGE = "F"
+
+	// J;{ENTITY_ID}:{OWNER_ID}:{WEAPON}:{POS}:{VELOCITY} from server when a projectile is fired, sent reliably
+	PROJECTILE_SPAWN_MESSAGE = "J"
+
+	// B;{ENTITY_ID}:{POS}:{HIT_PLAYER_ID} from server when a projectile explodes, hit player is 0 for misses, sent reliably
+	PROJECTILE_IMPACT_MESSAGE = "B"
+
+	// L;{NAME}, L;{NAME}:{TEAM}, L;{NAME}:{TEAM}:{MODE} or L;{NAME}:{TEAM}:{MODE}:{ROOM} from client,
+	// a missing or invalid team is auto assigned, MODE is LOGIN_MODE_SPECTATE to watch instead of play
+	// and empty to play, a missing ROOM is DEFAULT_ROOM_ID
 	PLAYER_LOGIN_MESSAGE = "L"
 
-	// I;{NEW_PLAYER_ID}:{NEW_POS}:{TIMESTAMP};{ID1}:{POS1}:{TIMESTAMP};{ID2}:{POS2}:{TIMESTAMP} from server to the new client
+	// D;{REASON}:{DETAIL} from server when a login is refused, REASON is one of the LOGIN_REJECT_* constants
+	LOGIN_REJECTED_MESSAGE = "D"
+
+	// I;{NEW_PLAYER_STATE};{PLAYER_STATE1};{PLAYER_STATE2};#{ENTITY1};@{CHAT1} from server to the new client, same layout as S
+	// with recent chat at the end in the server G layout
 	INITIAL_MESSAGE = "I"
 
-	// N;{NEW_PLAYER_ID}:{NEW_POS}:{TIMESTAMP} from server to all existing clients
+	// W;{PLAYER_STATE1};{PLAYER_STATE2};#{ENTITY1};@{CHAT1} from server to a new spectator, same layout as I
+	SPECTATOR_INIT_MESSAGE = "W"
+
+	// O;free, O;follow:{ID} or O;next from a spectator, O;{CAMERA_MODE}:{FOLLOW_ID} from server to confirm,
+	// the follow ID is 0 in free camera
+	SPECTATOR_CAMERA_MESSAGE = "O"
+
+	// G;{SCOPE}:{TARGET_ID}:{TEXT} from client, G;{SENDER_ID}:{SCOPE}:{TARGET_ID}:{TEXT} from server, sent reliably,
+	// the target is only used for whispers and is 0 otherwise
+	CHAT_MESSAGE = "G"
+
+	// Z;{PLAYER_ID}:{MUTED} from client, 1 hides chat from that player and 0 shows it again
+	CHAT_MUTE_MESSAGE = "Z"
+
+	// V;{TYPE}:{ARG} from client to start a vote, V;yes or V;no to cast one,
+	// V;{TYPE}:{ARG}:{STATE}:{YES}:{NO}:{NEEDED}:{REMAINING_MS} from server whenever the vote changes, sent reliably
+	VOTE_MESSAGE = "V"
+
+	// A;{ACTION}:{VALUE} from client during warmup, A;{ID}:{NAME}:{TEAM}:{LOADOUT}:{READY};{ID2}:... from server
+	// to everyone whenever the lobby changes and once more when the match starts, sent reliably
+	LOBBY_MESSAGE = "A"
+
+	// N;{NEW_PLAYER_STATE} from server to all existing clients, the full state in the server S layout including team
 	NEW_PLAYER_MESSAGE = "N"
 
-	// R;{POS}:{HEALTH}
+	// R;{POS}:{ROT}:{HEALTH}:{ARMOR}
 	PLAYER_RESET_MESSAGE = "R"
 
-	// P;{ID1}:{SCORE}:{DEATHS};{ID2}:{SCORE}:{DEATHS}
+	// P;{ID1}:{TEAM}:{SCORE}:{DEATHS}:{ASSISTS}:{HEADSHOTS};{ID2}:{TEAM}:{SCORE}:{DEATHS}:{ASSISTS}:{HEADSHOTS}
 	POINTS_MESSAGE = "P"
+
+	// T;{TEAM1}:{SCORE};{TEAM2}:{SCORE} from server, only sent in team modes
+	TEAM_SCORES_MESSAGE = "T"
+
+	// Q; from a leaving client, Q;{ID} from server to the remaining clients
+	PLAYER_LEAVE_MESSAGE = "Q"
+
+	// M;{PHASE}:{REMAINING_MS} from server on phase changes and every MATCH_CLOCK_SYNC_MS, -1 means no deadline
+	MATCH_PHASE_MESSAGE = "M"
+
+	// K;{KILLER_ID}:{VICTIM_ID}:{WEAPON}:{FLAGS} from server, sent reliably, the killer is ENVIRONMENT_ID for deaths nobody is credited with
+	KILL_MESSAGE = "K"
+
+	// X;{EVENT}:{PLAYER_ID}:{COUNT}:{ENDED_BY_ID} streak and multikill events from server, sent reliably
+	STREAK_MESSAGE = "X"
+
+	// U;{PICKUP_ID}:{TYPE}:{POS};{PICKUP_ID}:{TYPE}:{POS} from server when pickups spawn, and all active pickups on login
+	PICKUP_SPAWN_MESSAGE = "U"
+
+	// C;{PICKUP_ID}:{PLAYER_ID}:{RESPAWN_IN_MS} from server when a pickup is collected, sent reliably
+	PICKUP_COLLECT_MESSAGE = "C"
+
+	// Y;{SEQ};{PACKET} reliable wrapper from server, Y;{SEQ} ack from client
+	RELIABLE_MESSAGE = "Y"
+
+	// E;{WINNER_ID};{ID1}:{TEAM}:{SCORE}:{DEATHS}:{ASSISTS}:{HEADSHOTS};... final standings from server, winner is a team ID in team modes
+	MATCH_RESULTS_MESSAGE = "E"
+)
+
+const (
+	GAME_MODE_FREE_FOR_ALL    = "ffa"
+	GAME_MODE_TEAM_DEATHMATCH = "tdm"
+)
+
+const GAME_TICK_MS = 50
+
+const (
+	ENTITY_TYPE_PLAYER     = "player"
+	ENTITY_TYPE_PICKUP     = "pickup"
+	ENTITY_TYPE_PROJECTILE = "projectile"
+	ENTITY_TYPE_SPECTATOR  = "spectator"
+)
+
+const (
+	COMPONENT_PLAYER     = "player"
+	COMPONENT_TRANSFORM  = "transform"
+	COMPONENT_PICKUP     = "pickup"
+	COMPONENT_PROJECTILE = "projectile"
+	COMPONENT_BOT        = "bot"
+	COMPONENT_SPECTATOR  = "spectator"
+)
+
+// non player entities in S and I messages start with this so clients can tell them apart from player IDs
+const ENTITY_SNAPSHOT_PREFIX = "#"
+
+// chat history entries in I and W messages start with this
+const CHAT_HISTORY_PREFIX = "@"
+
+const (
+	CHAT_SCOPE_ALL     = "all"
+	CHAT_SCOPE_TEAM    = "team"
+	CHAT_SCOPE_WHISPER = "whisper"
+)
+
+const (
+	CHAT_MAX_LENGTH     = 200
+	CHAT_RATE_LIMIT     = 5 // messages per window
+	CHAT_RATE_WINDOW_MS = 5 * 1000
+	CHAT_HISTORY_SIZE   = 20
+)
+
+const (
+	VOTE_TYPE_KICK    = "kick"    // ARG is the player ID
+	VOTE_TYPE_MAP     = "map"     // ARG is a map file name in MAP_DIR without the extension
+	VOTE_TYPE_RESTART = "restart" // ARG is empty
+)
+
+const (
+	VOTE_STATE_OPEN   = "open"
+	VOTE_STATE_PASSED = "passed"
+	VOTE_STATE_FAILED = "failed"
+)
+
+const (
+	VOTE_YES = "yes"
+	VOTE_NO  = "no"
+)
+
+const (
+	// a vote passes once more than this share of the human players voted yes
+	VOTE_PASS_RATIO  = 0.5
+	VOTE_TIMEOUT_MS  = 30 * 1000
+	VOTE_COOLDOWN_MS = 60 * 1000 // between two votes started by the same player
+	// kicks need this many yes votes however few players are left to vote
+	VOTE_KICK_MIN_YES = 2
+)
+
+// the room clients join when their login doesnt name one
+const DEFAULT_ROOM_ID = "main"
+
+const LOGIN_MODE_SPECTATE = "spectate"
+
+const (
+	LOGIN_REJECT_NAME_LENGTH  = "name_length"  // detail is {MIN}-{MAX}
+	LOGIN_REJECT_NAME_CHARS   = "name_chars"   // detail is the allowed symbols besides letters and digits
+	LOGIN_REJECT_NAME_BLOCKED = "name_blocked" // detail is empty
+	LOGIN_REJECT_BANNED       = "banned"       // kicked by a vote, detail is empty, lasts until the match ends
+	// detail is the place in the join queue starting at 1, or 0 when the queue is full too.
+	// Queued clients resend L to keep their place and learn their new position
+	LOGIN_REJECT_FULL = "full"
+	LOGIN_REJECT_ROOM = "room" // detail is the room ID, sent for unknown rooms and to everyone in a room that closes
+)
+
+const (
+	MAX_PLAYERS          = 16
+	RESERVED_ADMIN_SLOTS = 0
+	JOIN_QUEUE_SIZE      = 8
+	// queued clients that havent resent L for this long lose their place
+	JOIN_QUEUE_TIMEOUT_MS = 10 * 1000
+)
+
+const (
+	NAME_MIN_LENGTH      = 2
+	NAME_MAX_LENGTH      = 16
+	NAME_ALLOWED_SYMBOLS = " _-."
+	// taken names get a counter added, like name(2)
+	NAME_DUPLICATE_FORMAT = "%s(%d)"
+	// characters the protocol uses as separators, never allowed in names
+	NAME_RESERVED_SYMBOLS = ";:,"
+)
+
+const (
+	CAMERA_MODE_FOLLOW = "follow"
+	CAMERA_MODE_FREE   = "free"
+	// not a mode, asks to follow the player after the current one
+	CAMERA_NEXT = "next"
+)
+
+const (
+	// warmup doubles as the lobby where players pick a team and loadout and ready up
+	MATCH_PHASE_WARMUP     = "warmup"
+	MATCH_PHASE_LIVE       = "live"
+	MATCH_PHASE_POST_MATCH = "post"
+)
+
+const (
+	MATCH_MIN_PLAYERS   = 2
+	MATCH_WARMUP_MS     = 30 * 1000
+	MATCH_TIME_LIMIT_MS = 10 * 60 * 1000
+	MATCH_SCORE_LIMIT   = 25
+	MATCH_POST_MATCH_MS = 10 * 1000
+	MATCH_CLOCK_SYNC_MS = 1000
+)
+
+const (
+	LOBBY_ACTION_READY   = "ready"   // VALUE is 1 or 0
+	LOBBY_ACTION_TEAM    = "team"    // VALUE is the team, only in team modes
+	LOBBY_ACTION_LOADOUT = "loadout" // VALUE is the only weapon the player can use once the match is live
+)
+
+// the match starts before the warmup countdown ends once this share of the human players is ready
+const LOBBY_READY_RATIO = 1.0
+
+const (
+	RELIABLE_RESEND_MS    = 200
+	RELIABLE_MAX_ATTEMPTS = 10
+)
+
+const (
+	WEAPON_RIFLE   = "rifle"
+	WEAPON_ROCKET  = "rocket"
+	WEAPON_GRENADE = "grenade"
+	WEAPON_DEFAULT = WEAPON_RIFLE
+
+	// not real weapons, reported in K messages for deaths caused by the map
+	WEAPON_HAZARD        = "hazard"
+	WEAPON_OUT_OF_BOUNDS = "bounds"
+)
+
+const (
+	PLAYER_HIT_RADIUS = 0.4
+	PLAYER_HIT_HEIGHT = 1.8
+	PLAYER_EYE_HEIGHT = 1.6
+	// the hit volume is split by height into legs, body and a narrower head
+	PLAYER_LEGS_HEIGHT = 0.8
+	PLAYER_NECK_HEIGHT = 1.45
+	PLAYER_HEAD_RADIUS = 0.2
+)
+
+const (
+	HIT_ZONE_HEAD = "head"
+	HIT_ZONE_BODY = "body"
+	HIT_ZONE_LIMB = "limb"
+)
+
+const (
+	ACTION_FLAG_CROUCH = 1 << 0
+	ACTION_FLAG_SPRINT = 1 << 1
+	ACTION_FLAG_AIM    = 1 << 2
+	ACTION_FLAG_RELOAD = 1 << 3
+)
+
+const PLAYER_CROUCH_HEIGHT = 1.2
+
+const (
+	PLAYER_WALK_SPEED   = 6.0
+	PLAYER_SPRINT_SPEED = 10.0
+	PLAYER_CROUCH_SPEED = 3.0
+	// movement checks allow this much over the limit for jitter, plus a flat slack in units
+	SPEED_TOLERANCE = 1.2
+	SPEED_SLACK     = 0.5
+	// updates closer together than this share one allowance for this long
+	SPEED_CHECK_MIN_MS = 100
+)
+
+const (
+	BOT_TARGET_PLAYERS = 0 // off unless an operator opts in with Config.BotTargetPlayers
+	BOT_NAME_PREFIX    = "Bot"
+	BOT_ACCURACY       = 0.35
+	BOT_REACTION_MS    = 600
+	// fixed bot behaviour
+	BOT_FIRE_INTERVAL_MS = 400
+	BOT_HEADSHOT_CHANCE  = 0.1
+	BOT_SIGHT_RANGE      = 40.0
+	BOT_WALK_SPEED       = 4.0
+	BOT_ARRIVE_DISTANCE  = 1.0
+	BOT_MAX_STEP_MS      = 250
+)
+
+// how far back shots can be checked against old positions
+const POSITION_HISTORY_MS = 1000
+
+const (
+	// players whose last update is older than this are extrapolated in broadcasts
+	EXTRAPOLATE_AFTER_MS = 100
+	// after this the player is left where the prediction ended instead of drifting further
+	MAX_EXTRAPOLATION_MS = 300
+)
+
+// projectiles fired further than this from the shooters eyes are moved back to the eyes
+const PROJECTILE_MAX_ORIGIN_OFFSET = 2.0
+
+// splash is traced from this far in front of the impact, so a wall the projectile hit doesnt shield its own side
+const PROJECTILE_SPLASH_OFFSET = 0.05
+
+const (
+	KILL_FLAG_HEADSHOT  = 1 << 0
+	KILL_FLAG_STREAK    = 1 << 1
+	KILL_FLAG_TEAM_KILL = 1 << 2
+	KILL_FLAG_SUICIDE   = 1 << 3
+	// the map killed the player and nobody gets the credit, the killer ID is ENVIRONMENT_ID
+	KILL_FLAG_ENVIRONMENT = 1 << 4
 )
 
+// player IDs start at 1 so 0 stands in for the map as a killer
+const ENVIRONMENT_ID = 0
+
+const (
+	BOUNDS_ACTION_SNAP = "snap" // move the player back to their last valid position
+	BOUNDS_ACTION_KILL = "kill"
+)
+
+// a player who dies to the map within this long of being hit is credited to whoever hit them last
+const ENVIRONMENT_CREDIT_WINDOW_MS = 5 * 1000
+
+const (
+	STREAK_EVENT_MILESTONE  = "streak"
+	STREAK_EVENT_MULTI_KILL = "multi"
+	STREAK_EVENT_ENDED      = "ended"
+)
+
+const (
+	STREAK_ANNOUNCE_MIN  = 3
+	MULTI_KILL_WINDOW_MS = 3 * 1000
+)
+
+const BUFF_DOUBLE_DAMAGE = "damage"
+
 const MAX_HEALTH = 5
 
+const (
+	PICKUP_TYPE_HEALTH        = "health"
+	PICKUP_TYPE_ARMOR         = "armor"
+	PICKUP_TYPE_AMMO          = "ammo"
+	PICKUP_TYPE_DOUBLE_DAMAGE = "damage"
+)
+
+const PICKUP_DEFAULT_RADIUS = 1.0
+
+const MAX_ARMOR = 5
+
+const (
+	REGEN_DELAY_MS    = 5 * 1000
+	REGEN_INTERVAL_MS = 1000
+	REGEN_AMOUNT      = 1
+)
+
+const (
+	ASSIST_MIN_DAMAGE = 2
+	ASSIST_WINDOW_MS  = 10 * 1000
+)
+
+const NO_TEAM = 0
+
+const MAX_TEAMS = 4
+
 const RESPAWN_IDLE_DELAY_MS = 2 * 1000 // 2 seconds
+
+const SPAWN_PROTECTION_MS = 3 * 1000 // 3 seconds after the respawn delay
+
+const SPAWN_REUSE_COOLDOWN_MS = 5 * 1000 // 5 seconds
+
+// random spots tried in a spawn zone before falling back to its center
+const SPAWN_POSITION_ATTEMPTS = 10
+
+const DEFAULT_MAP_PATH = "maps/default.json"
+
+// rooms hosted next to the default room, see RoomDef
+const DEFAULT_ROOMS_PATH = "rooms.json"
+
+// map votes can pick any map file in this directory
+const MAP_DIR = "maps"
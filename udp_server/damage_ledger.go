@@ -0,0 +1,84 @@
+package udp_server
+
+import "sync"
+
+type DamageRecord struct {
+	AttackerID int
+	Damage     int
+	At         int64
+}
+
+// DamageLedger remembers who damaged each player since their last death
+type DamageLedger struct {
+	mu      sync.Mutex
+	records map[int][]DamageRecord // victim ID to damage taken
+}
+
+func NewDamageLedger() *DamageLedger {
+	return &DamageLedger{
+		records: make(map[int][]DamageRecord),
+	}
+}
+
+// Record adds damage a player took and drops their records older than keepMs, so a player who stays alive
+// for a long time doesnt pile up damage nobody can be credited for anymore
+func (dl *DamageLedger) Record(victimID int, attackerID int, damage int, at int64, keepMs int64) {
+	dl.mu.Lock()
+	defer dl.mu.Unlock()
+	kept := []DamageRecord{}
+	for _, record := range dl.records[victimID] {
+		if at-record.At <= keepMs {
+			kept = append(kept, record)
+		}
+	}
+	dl.records[victimID] = append(kept, DamageRecord{AttackerID: attackerID, Damage: damage, At: at})
+}
+
+// Assisters returns every attacker other than the killer who dealt at least minDamage to the victim since now-windowMs
+func (dl *DamageLedger) Assisters(victimID int, killerID int, now int64, minDamage int, windowMs int64) []int {
+	dl.mu.Lock()
+	defer dl.mu.Unlock()
+
+	damageByAttacker := make(map[int]int)
+	attackers := []int{}
+	for _, record := range dl.records[victimID] {
+		if record.AttackerID == killerID || record.AttackerID == victimID || now-record.At > windowMs {
+			continue
+		}
+		if _, ok := damageByAttacker[record.AttackerID]; !ok {
+			attackers = append(attackers, record.AttackerID)
+		}
+		damageByAttacker[record.AttackerID] += record.Damage
+	}
+
+	assisters := []int{}
+	for _, attackerID := range attackers {
+		if damageByAttacker[attackerID] >= minDamage {
+			assisters = append(assisters, attackerID)
+		}
+	}
+	return assisters
+}
+
+// LastAttacker returns whoever other than the victim damaged them most recently within the window
+func (dl *DamageLedger) LastAttacker(victimID int, now int64, windowMs int64) (int, bool) {
+	dl.mu.Lock()
+	defer dl.mu.Unlock()
+	records := dl.records[victimID]
+	for i := len(records) - 1; i >= 0; i-- {
+		if now-records[i].At > windowMs {
+			break
+		}
+		if records[i].AttackerID != victimID {
+			return records[i].AttackerID, true
+		}
+	}
+	return 0, false
+}
+
+// Clear forgets the damage a player took, called when they die or leave
+func (dl *DamageLedger) Clear(victimID int) {
+	dl.mu.Lock()
+	defer dl.mu.Unlock()
+	delete(dl.records, victimID)
+}
@@ -0,0 +1,50 @@
+package udp_server
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAssisters(t *testing.T) {
+	dl := NewDamageLedger()
+	victimID, killerID := 1, 2
+	dl.Record(victimID, 3, 1, 0, 10000)    // too old
+	dl.Record(victimID, 3, 1, 9000, 10000) // 3 only has 1 damage in the window
+	dl.Record(victimID, 4, 1, 9000, 10000) // 4 adds up to 2
+	dl.Record(victimID, 4, 1, 9500, 10000)
+	dl.Record(victimID, killerID, 3, 9900, 10000)
+
+	assert.Equal(t, []int{4}, dl.Assisters(victimID, killerID, 10000, 2, 5000))
+
+	dl.Clear(victimID)
+	assert.Empty(t, dl.Assisters(victimID, killerID, 10000, 2, 5000))
+}
+
+func TestRecordDropsOldDamage(t *testing.T) {
+	dl := NewDamageLedger()
+	for at := int64(0); at < 100000; at += 1000 {
+		dl.Record(1, 2, 1, at, 5000)
+	}
+	assert.Len(t, dl.records[1], 6)
+}
+
+func TestAssistAwardedOnKill(t *testing.T) {
+	pm, _ := newMatchPlayerManager(t)
+	killer, _ := pm.CreatePlayer(newTestAddr(1), "killer", NO_TEAM)
+	helper, _ := pm.CreatePlayer(newTestAddr(2), "helper", NO_TEAM)
+	victim, _ := pm.CreatePlayer(newTestAddr(3), "victim", NO_TEAM)
+
+	shootPlayer(pm, helper, victim)
+	shootPlayer(pm, helper, victim)
+	for i := 0; i < MAX_HEALTH-2; i++ {
+		shootPlayer(pm, killer, victim)
+	}
+
+	helperState, _ := pm.GetPlayerState(helper.Addr.String())
+	assert.Equal(t, 1, helperState.Assists)
+	assert.Equal(t, 0, helperState.Score)
+	killerState, _ := pm.GetPlayerState(killer.Addr.String())
+	assert.Equal(t, 0, killerState.Assists)
+	assert.Equal(t, 1, killerState.Score)
+}
@@ -0,0 +1,206 @@
+package udp_server
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Component is a piece of typed entity data, ComponentType must not depend on the receivers value
+type Component interface {
+	ComponentType() string
+}
+
+// snapshotComponent is implemented by components that clients need in S and I messages
+type snapshotComponent interface {
+	SnapshotString() string
+}
+
+type Entity struct {
+	ID         int
+	Type       string // one of the ENTITY_TYPE_* constants
+	Key        string // optional unique lookup key, like a players address
+	Components map[string]Component
+}
+
+// Transform places non player entities in the world, players keep theirs in PlayerState
+type Transform struct {
+	Position Position
+	Rotation float32
+}
+
+func (t Transform) ComponentType() string {
+	return COMPONENT_TRANSFORM
+}
+
+func GetComponent[T Component](e Entity) (T, bool) {
+	var zero T
+	component, ok := e.Components[zero.ComponentType()]
+	if !ok {
+		return zero, false
+	}
+	typed, ok := component.(T)
+	return typed, ok
+}
+
+func (e *Entity) SetComponent(component Component) {
+	e.Components[component.ComponentType()] = component
+}
+
+func (e Entity) clone() Entity {
+	components := make(map[string]Component, len(e.Components))
+	for componentType, component := range e.Components {
+		components[componentType] = component
+	}
+	e.Components = components
+	return e
+}
+
+// SnapshotString encodes a non player entity as #{ID}:{TYPE}:{POS}:{ROT} followed by its snapshot components
+func (e Entity) SnapshotString() string {
+	strBuilder := strings.Builder{}
+	transform, _ := GetComponent[Transform](e)
+	strBuilder.WriteString(fmt.Sprintf("%s%d:%s:%s:%.3f", ENTITY_SNAPSHOT_PREFIX, e.ID, e.Type, transform.Position.String(), transform.Rotation))
+
+	componentTypes := []string{}
+	for componentType := range e.Components {
+		componentTypes = append(componentTypes, componentType)
+	}
+	sort.Strings(componentTypes)
+	for _, componentType := range componentTypes {
+		if sc, ok := e.Components[componentType].(snapshotComponent); ok {
+			strBuilder.WriteString(fmt.Sprintf(":%s", sc.SnapshotString()))
+		}
+	}
+	return strBuilder.String()
+}
+
+// EntityRegistry owns every entity in the world and hands out IDs that are never reused
+type EntityRegistry struct {
+	mu       sync.RWMutex
+	nextID   int
+	entities map[int]Entity
+	keys     map[string]int
+}
+
+func NewEntityRegistry() *EntityRegistry {
+	return &EntityRegistry{
+		entities: make(map[int]Entity),
+		keys:     make(map[string]int),
+	}
+}
+
+// ReserveID hands out the next entity ID, for callers that need the ID before building the entity
+func (er *EntityRegistry) ReserveID() int {
+	er.mu.Lock()
+	defer er.mu.Unlock()
+	er.nextID++
+	return er.nextID
+}
+
+func (er *EntityRegistry) Create(entityType string, key string, components ...Component) (Entity, error) {
+	return er.Add(er.ReserveID(), entityType, key, components...)
+}
+
+// Add stores a new entity under an ID from ReserveID
+func (er *EntityRegistry) Add(id int, entityType string, key string, components ...Component) (Entity, error) {
+	er.mu.Lock()
+	defer er.mu.Unlock()
+
+	if key != "" {
+		if _, ok := er.keys[key]; ok {
+			return Entity{}, fmt.Errorf("entity with key %s already exists", key)
+		}
+	}
+	if _, ok := er.entities[id]; ok || id > er.nextID || id < 1 {
+		return Entity{}, fmt.Errorf("entity ID %d was not reserved or is taken", id)
+	}
+	entity := Entity{
+		ID:         id,
+		Type:       entityType,
+		Key:        key,
+		Components: make(map[string]Component),
+	}
+	for _, component := range components {
+		entity.SetComponent(component)
+	}
+	er.entities[entity.ID] = entity
+	if key != "" {
+		er.keys[key] = entity.ID
+	}
+	return entity.clone(), nil
+}
+
+func (er *EntityRegistry) Get(id int) (Entity, bool) {
+	er.mu.RLock()
+	defer er.mu.RUnlock()
+	entity, ok := er.entities[id]
+	if !ok {
+		return Entity{}, false
+	}
+	return entity.clone(), true
+}
+
+func (er *EntityRegistry) GetByKey(key string) (Entity, bool) {
+	er.mu.RLock()
+	id, ok := er.keys[key]
+	er.mu.RUnlock()
+	if !ok {
+		return Entity{}, false
+	}
+	return er.Get(id)
+}
+
+// Modify applies fn to the stored entity under the registry lock, fn must not call back into the registry
+func (er *EntityRegistry) Modify(id int, fn func(e *Entity)) (Entity, error) {
+	er.mu.Lock()
+	defer er.mu.Unlock()
+	entity, ok := er.entities[id]
+	if !ok {
+		return Entity{}, fmt.Errorf("entity %d doesnt exist", id)
+	}
+	entity = entity.clone()
+	fn(&entity)
+	er.entities[id] = entity
+	return entity.clone(), nil
+}
+
+func (er *EntityRegistry) Remove(id int) (Entity, bool) {
+	er.mu.Lock()
+	defer er.mu.Unlock()
+	entity, ok := er.entities[id]
+	if !ok {
+		return Entity{}, false
+	}
+	delete(er.entities, id)
+	if entity.Key != "" {
+		delete(er.keys, entity.Key)
+	}
+	return entity, true
+}
+
+// Query returns every entity of the given type ordered by ID, an empty type matches all entities
+func (er *EntityRegistry) Query(entityType string) []Entity {
+	er.mu.RLock()
+	defer er.mu.RUnlock()
+	entities := []Entity{}
+	for _, entity := range er.entities {
+		if entityType == "" || entity.Type == entityType {
+			entities = append(entities, entity.clone())
+		}
+	}
+	sort.Slice(entities, func(i, j int) bool { return entities[i].ID < entities[j].ID })
+	return entities
+}
+
+// SnapshotEntities returns every entity that is not a player or spectator, for S and I messages
+func (er *EntityRegistry) SnapshotEntities() []Entity {
+	snapshot := []Entity{}
+	for _, entity := range er.Query("") {
+		if entity.Type != ENTITY_TYPE_PLAYER && entity.Type != ENTITY_TYPE_SPECTATOR {
+			snapshot = append(snapshot, entity)
+		}
+	}
+	return snapshot
+}
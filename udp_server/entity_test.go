@@ -0,0 +1,48 @@
+package udp_server
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEntityRegistry(t *testing.T) {
+	er := NewEntityRegistry()
+	first, err := er.Create(ENTITY_TYPE_PICKUP, "", Transform{Position: Position{x: 1}})
+	assert.Nil(t, err)
+	second, err := er.Create(ENTITY_TYPE_PLAYER, "player:a", PlayerState{Name: "a"})
+	assert.Nil(t, err)
+	assert.NotEqual(t, first.ID, second.ID)
+
+	_, err = er.Create(ENTITY_TYPE_PLAYER, "player:a")
+	assert.NotNil(t, err)
+
+	_, err = er.Modify(first.ID, func(e *Entity) {
+		e.SetComponent(Transform{Position: Position{x: 2}})
+	})
+	assert.Nil(t, err)
+	entity, ok := er.Get(first.ID)
+	assert.True(t, ok)
+	transform, ok := GetComponent[Transform](entity)
+	assert.True(t, ok)
+	assert.Equal(t, float32(2), transform.Position.x)
+
+	// IDs are never reused
+	er.Remove(first.ID)
+	third, _ := er.Create(ENTITY_TYPE_PICKUP, "")
+	assert.Greater(t, third.ID, second.ID)
+	assert.Equal(t, []Entity{third}, er.SnapshotEntities())
+}
+
+func TestSnapshotIncludesEntities(t *testing.T) {
+	pm := NewPlayerManager()
+	pm.SetMapData(MapData{
+		SpawnPoints: DefaultMapData().SpawnPoints,
+		Pickups:     []PickupDef{{Type: PICKUP_TYPE_AMMO, Position: Position{x: 1, y: 2, z: 3}}},
+	})
+	p1, _ := pm.CreatePlayer(newTestAddr(1), "p1", NO_TEAM)
+	p2, _ := pm.CreatePlayer(newTestAddr(2), "p2", NO_TEAM)
+
+	packet := parser.EncodePlayerStatesForBroadcast(pm.GetAllPlayerStates(nil), pm.GetSnapshotEntities())
+	assert.Equal(t, PLAYER_STATE_MESSAGE+";"+p1.String()+";"+p2.String()+";#1:pickup:1.000,2.000,3.000:0.000:ammo:1", packet)
+}
@@ -0,0 +1,84 @@
+package udp_server
+
+import "math"
+
+func (o Orientation) IsFinite() bool {
+	return Position{x: o.Yaw, y: o.Pitch}.IsFinite()
+}
+
+// Advance turns the orientation at the given angular velocity, wrapping yaw and stopping pitch at straight up or down
+func (o Orientation) Advance(angularVelocity Orientation, seconds float32) Orientation {
+	yaw := math.Mod(float64(o.Yaw+angularVelocity.Yaw*seconds), 360)
+	if yaw < 0 {
+		yaw += 360
+	}
+	pitch := o.Pitch + angularVelocity.Pitch*seconds
+	if pitch > 90 {
+		pitch = 90
+	}
+	if pitch < -90 {
+		pitch = -90
+	}
+	return Orientation{Yaw: float32(yaw), Pitch: pitch}
+}
+
+// Lerp turns the orientation a fraction of the way to another, yaw takes the short way around
+func (o Orientation) Lerp(to Orientation, fraction float32) Orientation {
+	turn := math.Mod(float64(to.Yaw-o.Yaw), 360)
+	if turn > 180 {
+		turn -= 360
+	}
+	if turn < -180 {
+		turn += 360
+	}
+	return o.Advance(Orientation{Yaw: float32(turn), Pitch: to.Pitch - o.Pitch}, fraction)
+}
+
+// extrapolate predicts where a player whose updates are late is now from their last velocities.
+// Prediction stops after MAX_EXTRAPOLATION_MS and never moves a player out of the world or into a wall
+func (pm *PlayerManager) extrapolate(ps PlayerState, now int64) PlayerState {
+	late := now - ps.ReceivedAt
+	if ps.ReceivedAt == 0 || late <= EXTRAPOLATE_AFTER_MS || ps.Health <= 0 {
+		return ps
+	}
+	if ps.Velocity == (Position{}) && ps.AngularVelocity == (Orientation{}) {
+		// standing still, the last state is still right
+		return ps
+	}
+	if late > MAX_EXTRAPOLATION_MS {
+		late = MAX_EXTRAPOLATION_MS
+	}
+	seconds := float32(late) / 1000
+	predicted := ps.Position.Add(pm.clampVelocity(ps.Velocity, ps.Actions).Scale(seconds))
+	if level := pm.currentLevel(); level.world.InBounds(predicted) && !level.geometry.Overlaps(playerBoxAt(predicted, ps.Actions)) {
+		ps.Position = predicted
+	}
+	ps.Rotation = ps.Rotation.Advance(ps.AngularVelocity, seconds)
+	// clients interpolate by timestamp, so the prediction is stamped with the time it is for
+	ps.LastUpdatedAt += late
+	return ps
+}
+
+// clampVelocity limits horizontal velocity to what checkSpeed would let the player move, so a client reporting a
+// huge velocity cant have the server carry it across the map. Falling is not limited, same as checkSpeed
+func (pm *PlayerManager) clampVelocity(velocity Position, actions int) Position {
+	if pm.config.WalkSpeed == 0 {
+		return velocity
+	}
+	allowed := pm.config.maxSpeed(actions) * SPEED_TOLERANCE
+	horizontal := Position{x: velocity.x, z: velocity.z}
+	if speed := horizontal.Length(); speed > allowed {
+		horizontal = horizontal.Scale(float32(allowed / speed))
+		velocity.x, velocity.z = horizontal.x, horizontal.z
+	}
+	return velocity
+}
+
+// GetBroadcastStates returns every player for S messages with late players extrapolated to now
+func (pm *PlayerManager) GetBroadcastStates(now int64) []PlayerState {
+	playerStates := pm.GetAllPlayerStates(nil)
+	for i, ps := range playerStates {
+		playerStates[i] = pm.extrapolate(ps, now)
+	}
+	return playerStates
+}
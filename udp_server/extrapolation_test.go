@@ -0,0 +1,59 @@
+package udp_server
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtrapolateLatePlayer(t *testing.T) {
+	pm := NewPlayerManager()
+	ps := PlayerState{
+		Health:          MAX_HEALTH,
+		Velocity:        Position{x: 5},
+		AngularVelocity: Orientation{Yaw: 100, Pitch: 100},
+		Rotation:        Orientation{Yaw: 350},
+		ReceivedAt:      1000,
+		LastUpdatedAt:   5000,
+	}
+
+	// on time, nothing to predict
+	assert.Equal(t, ps, pm.extrapolate(ps, 1000+EXTRAPOLATE_AFTER_MS))
+
+	predicted := pm.extrapolate(ps, 1200)
+	assert.InDelta(t, 1, predicted.Position.x, 0.001)
+	assert.InDelta(t, 10, predicted.Rotation.Yaw, 0.001)
+	assert.InDelta(t, 20, predicted.Rotation.Pitch, 0.001)
+	assert.Equal(t, int64(5200), predicted.LastUpdatedAt)
+
+	// long silences stop at the cap
+	predicted = pm.extrapolate(ps, 5000)
+	assert.InDelta(t, 5*MAX_EXTRAPOLATION_MS/1000.0, predicted.Position.x, 0.001)
+}
+
+func TestExtrapolateStopsAtWalls(t *testing.T) {
+	pm := NewPlayerManager()
+	pm.currentLevel().geometry = NewMapGeometry([]Box{{Min: Position{x: 1, y: 0, z: -1}, Max: Position{x: 2, y: 3, z: 1}}})
+	ps := PlayerState{Health: MAX_HEALTH, Velocity: Position{x: 10}, ReceivedAt: 1000}
+
+	predicted := pm.extrapolate(ps, 1200)
+	assert.Equal(t, Position{}, predicted.Position)
+}
+
+func TestExtrapolateClampsVelocity(t *testing.T) {
+	pm := NewPlayerManager()
+	ps := PlayerState{Health: MAX_HEALTH, Velocity: Position{x: 1000, y: -20}, ReceivedAt: 1000}
+
+	predicted := pm.extrapolate(ps, 2000)
+	seconds := float64(MAX_EXTRAPOLATION_MS) / 1000
+	assert.InDelta(t, pm.config.WalkSpeed*SPEED_TOLERANCE*seconds, predicted.Position.x, 0.001)
+	// falling isnt limited
+	assert.InDelta(t, -20*seconds, predicted.Position.y, 0.001)
+}
+
+func TestOrientationPitchIsClamped(t *testing.T) {
+	o := Orientation{Pitch: 80}.Advance(Orientation{Pitch: 100}, 1)
+	assert.Equal(t, float32(90), o.Pitch)
+	o = Orientation{Yaw: 10}.Advance(Orientation{Yaw: -20}, 1)
+	assert.Equal(t, float32(350), o.Yaw)
+}
@@ -0,0 +1,44 @@
+package udp_server
+
+// FreeForAll is a deathmatch, every kill is a point and everyone respawns
+type FreeForAll struct{}
+
+func (m *FreeForAll) Name() string {
+	return GAME_MODE_FREE_FOR_ALL
+}
+
+func (m *FreeForAll) OnJoin(pm *PlayerManager, ps *PlayerState, requestedTeam int) {
+	ps.Team = NO_TEAM
+}
+
+func (m *FreeForAll) OnLeave(pm *PlayerManager, ps PlayerState) {}
+
+func (m *FreeForAll) OnDamage(pm *PlayerManager, victim PlayerState, attacker PlayerState, damage int) int {
+	return damage
+}
+
+func (m *FreeForAll) OnDeath(pm *PlayerManager, victim PlayerState, killer PlayerState, lastUpdatedAt int64) {
+	pm.RespawnPlayer(victim.Addr.String(), lastUpdatedAt)
+	if killer.ID == victim.ID {
+		return
+	}
+	pm.AddPlayerScore(killer.Addr.String(), 1)
+}
+
+func (m *FreeForAll) OnBonusScore(pm *PlayerManager, ps PlayerState, score int) {
+	pm.AddPlayerScore(ps.Addr.String(), score)
+}
+
+func (m *FreeForAll) OnTick(pm *PlayerManager, now int64) {}
+
+func (m *FreeForAll) CheckWinCondition(pm *PlayerManager, timeUp bool) (int, bool) {
+	standings := pm.GetStandings()
+	if len(standings) == 0 {
+		return 0, timeUp
+	}
+	leader := standings[0]
+	if timeUp || (pm.config.ScoreLimit > 0 && leader.Score >= pm.config.ScoreLimit) {
+		return leader.ID, true
+	}
+	return 0, false
+}
@@ -0,0 +1,28 @@
+package udp_server
+
+// GameMode holds the rules of a match, PlayerManager calls into it at each hook
+type GameMode interface {
+	Name() string
+	// OnJoin runs before a new player is stored and can set the players team
+	OnJoin(pm *PlayerManager, ps *PlayerState, requestedTeam int)
+	OnLeave(pm *PlayerManager, ps PlayerState)
+	// OnDamage returns the damage the victim should take, 0 ignores the hit
+	OnDamage(pm *PlayerManager, victim PlayerState, attacker PlayerState, damage int) int
+	// OnDeath handles scoring and respawning once the victims health runs out
+	OnDeath(pm *PlayerManager, victim PlayerState, killer PlayerState, lastUpdatedAt int64)
+	// OnBonusScore credits score earned outside of kills, such as streak rewards
+	OnBonusScore(pm *PlayerManager, ps PlayerState, score int)
+	OnTick(pm *PlayerManager, now int64)
+	// CheckWinCondition returns the winning player or team ID once the match is decided,
+	// when timeUp is set it must return the current leader
+	CheckWinCondition(pm *PlayerManager, timeUp bool) (int, bool)
+}
+
+func NewGameMode(config Config) GameMode {
+	switch config.Mode {
+	case GAME_MODE_TEAM_DEATHMATCH:
+		return &TeamDeathmatch{}
+	default:
+		return &FreeForAll{}
+	}
+}
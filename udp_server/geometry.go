@@ -0,0 +1,51 @@
+package udp_server
+
+import "math"
+
+// MapGeometry is the static collision geometry of a map, made of solid axis aligned boxes
+type MapGeometry struct {
+	solids []Box
+}
+
+func NewMapGeometry(solids []Box) *MapGeometry {
+	return &MapGeometry{solids: solids}
+}
+
+// RayCast returns the fraction along from->to where the segment first enters solid geometry
+func (mg *MapGeometry) RayCast(from Position, to Position) (float64, bool) {
+	closest := math.Inf(1)
+	for _, solid := range mg.solids {
+		if fraction, ok := solid.IntersectSegment(from, to); ok && fraction < closest {
+			closest = fraction
+		}
+	}
+	if math.IsInf(closest, 1) {
+		return 0, false
+	}
+	return closest, true
+}
+
+// LineOfSight reports whether nothing solid is between the two points
+func (mg *MapGeometry) LineOfSight(from Position, to Position) bool {
+	_, blocked := mg.RayCast(from, to)
+	return !blocked
+}
+
+func (mg *MapGeometry) IsSolid(p Position) bool {
+	for _, solid := range mg.solids {
+		if solid.Contains(p) {
+			return true
+		}
+	}
+	return false
+}
+
+// Overlaps reports whether any solid intersects the box, used to keep players out of walls
+func (mg *MapGeometry) Overlaps(box Box) bool {
+	for _, solid := range mg.solids {
+		if solid.Intersects(box) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,109 @@
+package udp_server
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newWallGeometry() *MapGeometry {
+	// a wall across the z axis between z=4 and z=5
+	return NewMapGeometry([]Box{{Min: Position{x: -10, y: 0, z: 4}, Max: Position{x: 10, y: 5, z: 5}}})
+}
+
+func TestRayCastHitsNearestSolid(t *testing.T) {
+	mg := NewMapGeometry([]Box{
+		{Min: Position{x: -1, y: -1, z: 8}, Max: Position{x: 1, y: 1, z: 9}},
+		{Min: Position{x: -1, y: -1, z: 4}, Max: Position{x: 1, y: 1, z: 5}},
+	})
+	fraction, ok := mg.RayCast(Position{}, Position{z: 10})
+	assert.True(t, ok)
+	assert.InDelta(t, 0.4, fraction, 0.0001)
+
+	assert.False(t, mg.LineOfSight(Position{}, Position{z: 10}))
+	assert.True(t, mg.LineOfSight(Position{x: 5}, Position{x: 5, z: 10}))
+	assert.True(t, mg.IsSolid(Position{z: 4.5}))
+	assert.False(t, mg.IsSolid(Position{z: 6}))
+}
+
+func TestShotThroughWallIsRejected(t *testing.T) {
+	pm, _ := newMatchPlayerManager(t)
+	pm.currentLevel().geometry = newWallGeometry()
+	shooter, _ := pm.CreatePlayer(newTestAddr(1), "shooter", NO_TEAM)
+	victim, _ := pm.CreatePlayer(newTestAddr(2), "victim", NO_TEAM)
+	placePlayer(pm, shooter, Position{})
+	placePlayer(pm, victim, Position{z: 10})
+
+	shootPlayerIn(pm, shooter, victim, HIT_ZONE_BODY)
+	victimState, _ := pm.GetPlayerState(victim.Addr.String())
+	assert.Equal(t, MAX_HEALTH, victimState.Health)
+
+	// step out from behind the wall
+	placePlayer(pm, victim, Position{x: 30, z: 10})
+	shootPlayerIn(pm, shooter, victim, HIT_ZONE_BODY)
+	victimState, _ = pm.GetPlayerState(victim.Addr.String())
+	assert.Equal(t, MAX_HEALTH-1, victimState.Health)
+}
+
+func TestProjectileStopsAtWall(t *testing.T) {
+	pm, _ := newMatchPlayerManager(t)
+	pm.currentLevel().geometry = newWallGeometry()
+	shooter, _ := pm.CreatePlayer(newTestAddr(1), "shooter", NO_TEAM)
+	victim, _ := pm.CreatePlayer(newTestAddr(2), "victim", NO_TEAM)
+	placePlayer(pm, shooter, Position{})
+	placePlayer(pm, victim, Position{z: 10})
+
+	fire := Fire{Weapon: WEAPON_ROCKET, Origin: Position{y: 1}, Direction: Position{z: 1}}
+	projectile, _ := pm.FireProjectile(shooter.Addr.String(), fire, 0)
+	pm.tickProjectiles(1000)
+
+	_, ok := pm.entities.Get(projectile.ID)
+	assert.False(t, ok)
+	victimState, _ := pm.GetPlayerState(victim.Addr.String())
+	assert.Equal(t, MAX_HEALTH, victimState.Health)
+}
+
+func TestWallShieldsFromSplash(t *testing.T) {
+	pm, _ := newMatchPlayerManager(t)
+	pm.currentLevel().geometry = newWallGeometry()
+	shooter, _ := pm.CreatePlayer(newTestAddr(1), "shooter", NO_TEAM)
+	behind, _ := pm.CreatePlayer(newTestAddr(2), "behind", NO_TEAM)
+	beside, _ := pm.CreatePlayer(newTestAddr(3), "beside", NO_TEAM)
+	placePlayer(pm, shooter, Position{})
+	// both within splash range of where the rocket hits the wall, on either side of it
+	placePlayer(pm, behind, Position{z: 6})
+	placePlayer(pm, beside, Position{x: 1.5, z: 3})
+
+	fire := Fire{Weapon: WEAPON_ROCKET, Origin: Position{y: 1}, Direction: Position{z: 1}}
+	pm.FireProjectile(shooter.Addr.String(), fire, 0)
+	pm.tickProjectiles(1000)
+
+	behindState, _ := pm.GetPlayerState(behind.Addr.String())
+	assert.Equal(t, MAX_HEALTH, behindState.Health)
+	besideState, _ := pm.GetPlayerState(beside.Addr.String())
+	assert.Less(t, besideState.Health, MAX_HEALTH)
+}
+
+func TestSpawnZoneAvoidsSolids(t *testing.T) {
+	// the solid covers the whole zone apart from its center
+	zone := SpawnPoint{Position: Position{x: 0, y: 0, z: 0}, Extent: Position{x: 5, y: 0, z: 5}}
+	sm := NewSpawnManager([]SpawnPoint{zone}, NewMapGeometry([]Box{{Min: Position{x: 0.5, y: 0, z: -5}, Max: Position{x: 5, y: 2, z: 5}}}))
+	for i := 0; i < 20; i++ {
+		pos := sm.PickSpawnPosition(nil)
+		assert.False(t, sm.geometry.Overlaps(playerBoxAt(pos, 0)))
+	}
+}
+
+func TestLoadMapDataRejectsSpawnInsideSolid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "map.json")
+	data := `{
+		"name": "walled",
+		"spawnPoints": [{ "name": "stuck", "position": [0, 0, 0] }],
+		"geometry": [{ "name": "wall", "min": [-1, 0, -1], "max": [1, 3, 1] }]
+	}`
+	assert.Nil(t, os.WriteFile(path, []byte(data), 0644))
+	_, err := LoadMapData(path)
+	assert.NotNil(t, err)
+}
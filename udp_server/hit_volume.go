@@ -0,0 +1,144 @@
+package udp_server
+
+// Box is an axis aligned box
+type Box struct {
+	Name string   `json:"name"`
+	Min  Position `json:"min"`
+	Max  Position `json:"max"`
+}
+
+func (b Box) Contains(p Position) bool {
+	return p.x >= b.Min.x && p.x <= b.Max.x &&
+		p.y >= b.Min.y && p.y <= b.Max.y &&
+		p.z >= b.Min.z && p.z <= b.Max.z
+}
+
+// Intersects reports whether the boxes overlap, boxes that only touch dont count so players can stand on floors
+func (b Box) Intersects(other Box) bool {
+	return b.Min.x < other.Max.x && b.Max.x > other.Min.x &&
+		b.Min.y < other.Max.y && b.Max.y > other.Min.y &&
+		b.Min.z < other.Max.z && b.Max.z > other.Min.z
+}
+
+func (b Box) IsValid() bool {
+	return b.Min.IsFinite() && b.Max.IsFinite() &&
+		b.Min.x <= b.Max.x && b.Min.y <= b.Max.y && b.Min.z <= b.Max.z
+}
+
+func (b Box) Center() Position {
+	return b.Min.Add(b.Max).Scale(0.5)
+}
+
+// IntersectSegment returns the fraction along from->to where the segment enters the box
+func (b Box) IntersectSegment(from Position, to Position) (float64, bool) {
+	tMin, tMax := 0.0, 1.0
+	starts := [3]float32{from.x, from.y, from.z}
+	ends := [3]float32{to.x, to.y, to.z}
+	mins := [3]float32{b.Min.x, b.Min.y, b.Min.z}
+	maxs := [3]float32{b.Max.x, b.Max.y, b.Max.z}
+	for axis := 0; axis < 3; axis++ {
+		delta := float64(ends[axis] - starts[axis])
+		if delta == 0 {
+			if starts[axis] < mins[axis] || starts[axis] > maxs[axis] {
+				return 0, false
+			}
+			continue
+		}
+		t1 := float64(mins[axis]-starts[axis]) / delta
+		t2 := float64(maxs[axis]-starts[axis]) / delta
+		if t1 > t2 {
+			t1, t2 = t2, t1
+		}
+		if t1 > tMin {
+			tMin = t1
+		}
+		if t2 < tMax {
+			tMax = t2
+		}
+		if tMin > tMax {
+			return 0, false
+		}
+	}
+	return tMin, true
+}
+
+// HitBox is the players hit volume, Position is taken to be at the players feet
+func (ps *PlayerState) HitBox() Box {
+	return playerBoxAt(ps.Position, ps.Actions)
+}
+
+// playerHeight is how tall the hit volume is, crouching players are shorter
+func playerHeight(actions int) float32 {
+	if actions&ACTION_FLAG_CROUCH != 0 {
+		return PLAYER_CROUCH_HEIGHT
+	}
+	return PLAYER_HIT_HEIGHT
+}
+
+// eyeHeight is where shots and projectiles start, it drops with the rest of the player when crouching
+func eyeHeight(actions int) float32 {
+	return PLAYER_EYE_HEIGHT * playerHeight(actions) / PLAYER_HIT_HEIGHT
+}
+
+func playerBoxAt(feet Position, actions int) Box {
+	return Box{
+		Min: Position{x: feet.x - PLAYER_HIT_RADIUS, y: feet.y, z: feet.z - PLAYER_HIT_RADIUS},
+		Max: Position{x: feet.x + PLAYER_HIT_RADIUS, y: feet.y + playerHeight(actions), z: feet.z + PLAYER_HIT_RADIUS},
+	}
+}
+
+// ZoneBox is the part of the hit volume of a player at feet that counts as the given zone,
+// zones keep their proportions when the player crouches
+func ZoneBox(feet Position, zone string, actions int) (Box, bool) {
+	radius := float32(PLAYER_HIT_RADIUS)
+	var bottom, top float32
+	switch zone {
+	case HIT_ZONE_LIMB:
+		bottom, top = 0, PLAYER_LEGS_HEIGHT
+	case HIT_ZONE_BODY:
+		bottom, top = PLAYER_LEGS_HEIGHT, PLAYER_NECK_HEIGHT
+	case HIT_ZONE_HEAD:
+		bottom, top, radius = PLAYER_NECK_HEIGHT, PLAYER_HIT_HEIGHT, PLAYER_HEAD_RADIUS
+	default:
+		return Box{}, false
+	}
+	scale := playerHeight(actions) / PLAYER_HIT_HEIGHT
+	bottom, top = bottom*scale, top*scale
+	return Box{
+		Name: zone,
+		Min:  Position{x: feet.x - radius, y: feet.y + bottom, z: feet.z - radius},
+		Max:  Position{x: feet.x + radius, y: feet.y + top, z: feet.z + radius},
+	}, true
+}
+
+// hitZoneOrder goes from the most to the least damage, a hit is only ever moved down this list
+var hitZoneOrder = []string{HIT_ZONE_HEAD, HIT_ZONE_BODY, HIT_ZONE_LIMB}
+
+// zoneRank is the position of a zone in hitZoneOrder, unknown zones rank as the body like a hit without a zone
+func zoneRank(zone string) int {
+	for rank, ordered := range hitZoneOrder {
+		if ordered == zone {
+			return rank
+		}
+	}
+	return zoneRank(HIT_ZONE_BODY)
+}
+
+// AimedZone casts the aim from eyes along rotation at a player at feet and returns the zone it enters first
+func AimedZone(eyes Position, rotation Orientation, feet Position, actions int) (string, bool) {
+	reach := float32(eyes.DistanceTo(feet)) + PLAYER_HIT_HEIGHT
+	end := eyes.Add(rotation.Direction().Scale(reach))
+	aimed, nearest := "", 0.0
+	for _, zone := range hitZoneOrder {
+		box, _ := ZoneBox(feet, zone, actions)
+		if entry, ok := box.IntersectSegment(eyes, end); ok && (aimed == "" || entry < nearest) {
+			aimed, nearest = zone, entry
+		}
+	}
+	return aimed, aimed != ""
+}
+
+// Center is the middle of the players hit volume, used for splash falloff
+func (ps *PlayerState) Center() Position {
+	return ps.Position.Add(Position{y: playerHeight(ps.Actions) / 2})
+}
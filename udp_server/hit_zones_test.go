@@ -0,0 +1,133 @@
+package udp_server
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func shootPlayerIn(pm *PlayerManager, shooter PlayerState, victim PlayerState, zone string) {
+	shot := Shot{HitPlayerID: victim.ID, LastUpdatedAt: time.Now().UnixMilli(), Weapon: WEAPON_RIFLE, Zone: zone}
+	pm.HandlePlayerShot(shot, shooter.Addr)
+}
+
+func TestZoneDamageMultipliers(t *testing.T) {
+	rifle, _ := GetWeapon(WEAPON_RIFLE)
+	assert.Equal(t, 3.0, rifle.ZoneDamage(HIT_ZONE_HEAD))
+	assert.Equal(t, 1.0, rifle.ZoneDamage(HIT_ZONE_BODY))
+	assert.Equal(t, 0.5, rifle.ZoneDamage(HIT_ZONE_LIMB))
+}
+
+func TestHeadshotKill(t *testing.T) {
+	pm, packets := newMatchPlayerManager(t)
+	shooter, _ := pm.CreatePlayer(newTestAddr(1), "shooter", NO_TEAM)
+	victim, _ := pm.CreatePlayer(newTestAddr(2), "victim", NO_TEAM)
+	placePlayer(pm, shooter, Position{})
+	placePlayer(pm, victim, Position{z: 10})
+
+	shootPlayerIn(pm, shooter, victim, HIT_ZONE_HEAD)
+	victimState, _ := pm.GetPlayerState(victim.Addr.String())
+	assert.Equal(t, MAX_HEALTH-3, victimState.Health)
+
+	shootPlayerIn(pm, shooter, victim, HIT_ZONE_HEAD)
+	shooterState, _ := pm.GetPlayerState(shooter.Addr.String())
+	assert.Equal(t, 1, shooterState.Headshots)
+	kill := fmt.Sprintf("%s;%d:%d:%s:%d", KILL_MESSAGE, shooter.ID, victim.ID, WEAPON_RIFLE, KILL_FLAG_HEADSHOT)
+	assert.True(t, hasReliablePacket(*packets, kill))
+}
+
+func TestLimbHitsAddUp(t *testing.T) {
+	pm, _ := newMatchPlayerManager(t)
+	shooter, _ := pm.CreatePlayer(newTestAddr(1), "shooter", NO_TEAM)
+	victim, _ := pm.CreatePlayer(newTestAddr(2), "victim", NO_TEAM)
+	placePlayer(pm, shooter, Position{})
+	placePlayer(pm, victim, Position{z: 10})
+
+	shootPlayerIn(pm, shooter, victim, HIT_ZONE_LIMB)
+	victimState, _ := pm.GetPlayerState(victim.Addr.String())
+	assert.Equal(t, MAX_HEALTH, victimState.Health)
+
+	shootPlayerIn(pm, shooter, victim, HIT_ZONE_LIMB)
+	victimState, _ = pm.GetPlayerState(victim.Addr.String())
+	assert.Equal(t, MAX_HEALTH-1, victimState.Health)
+	assert.Equal(t, 0.0, victimState.DamageCarry)
+}
+
+func TestHiddenHeadIsDowngraded(t *testing.T) {
+	pm, _ := newMatchPlayerManager(t)
+	// a ledge that covers the victims head but not their body
+	pm.currentLevel().geometry = NewMapGeometry([]Box{{Min: Position{x: -10, y: 1.45, z: 4}, Max: Position{x: 10, y: 1.9, z: 5}}})
+	shooter, _ := pm.CreatePlayer(newTestAddr(1), "shooter", NO_TEAM)
+	victim, _ := pm.CreatePlayer(newTestAddr(2), "victim", NO_TEAM)
+	placePlayer(pm, shooter, Position{})
+	placePlayer(pm, victim, Position{z: 10})
+
+	shootPlayerIn(pm, shooter, victim, HIT_ZONE_HEAD)
+	victimState, _ := pm.GetPlayerState(victim.Addr.String())
+	assert.Equal(t, MAX_HEALTH-1, victimState.Health)
+}
+
+func TestHeadClaimNeedsHeadAim(t *testing.T) {
+	pm, _ := newMatchPlayerManager(t)
+	shooter, _ := pm.CreatePlayer(newTestAddr(1), "shooter", NO_TEAM)
+	victim, _ := pm.CreatePlayer(newTestAddr(2), "victim", NO_TEAM)
+	placePlayer(pm, victim, Position{z: 10})
+	eyes := Position{y: PLAYER_EYE_HEIGHT}
+	aims := map[string]Orientation{
+		"body":  lookAt(eyes, Position{y: 1, z: 10}),
+		"sky":   {Pitch: 80},
+		"stern": {Yaw: 180},
+	}
+
+	for name, aim := range aims {
+		pm.UpdatePlayerState(shooter.Addr.String(), PlayerState{Rotation: aim, LastUpdatedAt: time.Now().UnixMilli()})
+		before, _ := pm.GetPlayerState(victim.Addr.String())
+		shootPlayerIn(pm, shooter, victim, HIT_ZONE_HEAD)
+		after, _ := pm.GetPlayerState(victim.Addr.String())
+		assert.Equal(t, before.Health-1, after.Health, name)
+	}
+}
+
+func TestShotUsesPositionAtFireTime(t *testing.T) {
+	pm, _ := newMatchPlayerManager(t)
+	pm.currentLevel().geometry = newWallGeometry()
+	shooter, _ := pm.CreatePlayer(newTestAddr(1), "shooter", NO_TEAM)
+	victim, _ := pm.CreatePlayer(newTestAddr(2), "victim", NO_TEAM)
+
+	now := time.Now().UnixMilli()
+	pm.history.Clear(shooter.ID)
+	pm.history.Clear(victim.ID)
+	pm.history.Record(shooter.ID, Position{}, lookAt(Position{y: PLAYER_EYE_HEIGHT}, Position{x: 60, y: 1, z: 10}), 0, now-200, now-200)
+	pm.history.Record(victim.ID, Position{x: 60, z: 10}, Orientation{}, 0, now-200, now-200)
+	// the victim ducked behind the wall after the shot was fired
+	pm.history.Record(victim.ID, Position{z: 10}, Orientation{}, 0, now-50, now-50)
+
+	shot := Shot{HitPlayerID: victim.ID, LastUpdatedAt: now - 150, Weapon: WEAPON_RIFLE, Zone: HIT_ZONE_BODY}
+	pm.HandlePlayerShot(shot, shooter.Addr)
+	victimState, _ := pm.GetPlayerState(victim.Addr.String())
+	assert.Equal(t, MAX_HEALTH-1, victimState.Health)
+}
+
+func TestPositionHistoryInterpolates(t *testing.T) {
+	ph := NewPositionHistory()
+	ph.Record(1, Position{x: 0}, Orientation{Yaw: 350}, 0, 1000, 0)
+	ph.Record(1, Position{x: 10}, Orientation{Yaw: 10, Pitch: 20}, 0, 1100, 0)
+
+	sample, ok := ph.At(1, 1050)
+	assert.True(t, ok)
+	assert.Equal(t, Position{x: 5}, sample.Position)
+	// yaw turns the short way through 0
+	assert.InDelta(t, 0, sample.Rotation.Yaw, 0.001)
+	assert.InDelta(t, 10, sample.Rotation.Pitch, 0.001)
+	sample, _ = ph.At(1, 500)
+	assert.Equal(t, Position{x: 0}, sample.Position)
+	sample, _ = ph.At(1, 2000)
+	assert.Equal(t, Position{x: 10}, sample.Position)
+
+	// samples older than the window are dropped
+	ph.Record(1, Position{x: 20}, Orientation{}, 0, 1100+POSITION_HISTORY_MS+1, 0)
+	sample, _ = ph.At(1, 1000)
+	assert.Equal(t, Position{x: 10}, sample.Position)
+}
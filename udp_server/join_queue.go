@@ -0,0 +1,170 @@
+package udp_server
+
+import (
+	"net"
+	"strconv"
+	"sync"
+)
+
+// QueuedLogin is a login waiting for a free slot
+type QueuedLogin struct {
+	Addr       *net.UDPAddr
+	Name       string
+	Team       int
+	Admin      bool
+	LastSeenAt int64 // last time the client sent L, old entries are dropped
+}
+
+// JoinQueue keeps waiting logins in the order they arrived
+type JoinQueue struct {
+	mu      sync.Mutex
+	entries []QueuedLogin
+}
+
+func NewJoinQueue() *JoinQueue {
+	return &JoinQueue{}
+}
+
+// Push queues a login or refreshes the one already queued from the same address.
+// It returns the place in the queue starting at 1, or 0 when the queue has no room
+func (jq *JoinQueue) Push(login QueuedLogin, size int) int {
+	jq.mu.Lock()
+	defer jq.mu.Unlock()
+	for i := range jq.entries {
+		if jq.entries[i].Addr.String() == login.Addr.String() {
+			jq.entries[i].LastSeenAt = login.LastSeenAt
+			return i + 1
+		}
+	}
+	if len(jq.entries) >= size {
+		return 0
+	}
+	jq.entries = append(jq.entries, login)
+	return len(jq.entries)
+}
+
+func (jq *JoinQueue) Remove(addrStr string) bool {
+	jq.mu.Lock()
+	defer jq.mu.Unlock()
+	for i, entry := range jq.entries {
+		if entry.Addr.String() == addrStr {
+			jq.entries = append(jq.entries[:i], jq.entries[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (jq *JoinQueue) Contains(addrStr string) bool {
+	jq.mu.Lock()
+	defer jq.mu.Unlock()
+	for _, entry := range jq.entries {
+		if entry.Addr.String() == addrStr {
+			return true
+		}
+	}
+	return false
+}
+
+func (jq *JoinQueue) Entries() []QueuedLogin {
+	jq.mu.Lock()
+	defer jq.mu.Unlock()
+	return append([]QueuedLogin{}, jq.entries...)
+}
+
+func (jq *JoinQueue) Len() int {
+	jq.mu.Lock()
+	defer jq.mu.Unlock()
+	return len(jq.entries)
+}
+
+// Expire drops clients that stopped asking for a slot
+func (jq *JoinQueue) Expire(now int64, timeoutMs int64) {
+	jq.mu.Lock()
+	defer jq.mu.Unlock()
+	kept := []QueuedLogin{}
+	for _, entry := range jq.entries {
+		if now-entry.LastSeenAt < timeoutMs {
+			kept = append(kept, entry)
+		}
+	}
+	jq.entries = kept
+}
+
+func (pm *PlayerManager) isAdmin(addr *net.UDPAddr) bool {
+	for _, host := range pm.config.AdminHosts {
+		if addr.IP.String() == host {
+			return true
+		}
+	}
+	return false
+}
+
+// hasFreeSlot reports whether a human player can join, only admins get the reserved slots
+func (pm *PlayerManager) hasFreeSlot(admin bool) bool {
+	if pm.config.MaxPlayers == 0 {
+		return true
+	}
+	humans := 0
+	for _, ps := range pm.GetAllPlayerStates(nil) {
+		if !isBotAddr(ps.Addr) {
+			humans++
+		}
+	}
+	limit := pm.config.MaxPlayers
+	if !admin {
+		limit -= pm.config.ReservedAdminSlots
+	}
+	return humans < limit
+}
+
+// admit lets a login through when there is a slot and nobody queued before it, otherwise it joins the queue
+// and gets a server full error with its place. Callers must hold joinMu
+func (pm *PlayerManager) admit(addr *net.UDPAddr, name string, requestedTeam int, now int64) error {
+	if isBotAddr(addr) {
+		return nil
+	}
+	admin := pm.isAdmin(addr)
+	if pm.joinQueue.Len() == 0 && pm.hasFreeSlot(admin) {
+		return nil
+	}
+	position := pm.joinQueue.Push(QueuedLogin{Addr: addr, Name: name, Team: requestedTeam, Admin: admin, LastSeenAt: now}, pm.config.JoinQueueSize)
+	logger.debug("Server full, client %s is at %d in the join queue", addr.String(), position)
+	return &LoginError{Reason: LOGIN_REJECT_FULL, Detail: strconv.Itoa(position)}
+}
+
+// LeaveJoinQueue takes a client that gave up waiting out of the queue. It holds joinMu so a client
+// that is about to be let in is either logged in or out of the queue by the time it returns
+func (pm *PlayerManager) LeaveJoinQueue(addrStr string) bool {
+	pm.joinMu.Lock()
+	defer pm.joinMu.Unlock()
+	return pm.joinQueue.Remove(addrStr)
+}
+
+func (pm *PlayerManager) IsQueued(addrStr string) bool {
+	return pm.joinQueue.Contains(addrStr)
+}
+
+// tickJoinQueue logs in queued clients in order as slots free up, an admin can get a reserved slot ahead of others
+func (pm *PlayerManager) tickJoinQueue(now int64) {
+	pm.joinQueue.Expire(now, JOIN_QUEUE_TIMEOUT_MS)
+	if pm.joinQueue.Len() == 0 {
+		return
+	}
+	pm.joinMu.Lock()
+	defer pm.joinMu.Unlock()
+	for _, entry := range pm.joinQueue.Entries() {
+		if !pm.hasFreeSlot(entry.Admin) {
+			continue
+		}
+		pm.joinQueue.Remove(entry.Addr.String())
+		// the name was checked when queueing but someone may have taken it since
+		playerState, err := pm.addPlayer(entry.Addr, pm.uniqueName(entry.Name), entry.Team)
+		if err != nil {
+			logger.warn(err.Error())
+			continue
+		}
+		pm.WelcomePlayer(playerState)
+		logger.info("Player %d logged in from the join queue: %s", playerState.ID, playerState.Name)
+	}
+}
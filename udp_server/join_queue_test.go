@@ -0,0 +1,89 @@
+package udp_server
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func withTwoSlots(config *Config) {
+	config.MaxPlayers = 2
+	config.JoinQueueSize = 2
+}
+
+func fillServer(pm *PlayerManager) {
+	pm.CreatePlayer(newTestAddr(1), "first", NO_TEAM)
+	pm.CreatePlayer(newTestAddr(2), "second", NO_TEAM)
+}
+
+func TestServerFullQueuesLogins(t *testing.T) {
+	pm, packets := newMatchPlayerManager(t, withTwoSlots)
+	fillServer(pm)
+
+	_, err := pm.CreatePlayer(newTestAddr(3), "third", NO_TEAM)
+	assert.Equal(t, &LoginError{Reason: LOGIN_REJECT_FULL, Detail: "1"}, err)
+	_, err = pm.CreatePlayer(newTestAddr(4), "fourth", NO_TEAM)
+	assert.Equal(t, &LoginError{Reason: LOGIN_REJECT_FULL, Detail: "2"}, err)
+	_, err = pm.CreatePlayer(newTestAddr(5), "fifth", NO_TEAM)
+	assert.Equal(t, &LoginError{Reason: LOGIN_REJECT_FULL, Detail: "0"}, err)
+	// resending the login keeps the same place
+	_, err = pm.CreatePlayer(newTestAddr(3), "third", NO_TEAM)
+	assert.Equal(t, &LoginError{Reason: LOGIN_REJECT_FULL, Detail: "1"}, err)
+
+	now := time.Now().UnixMilli()
+	pm.RemovePlayer(newTestAddr(1).String())
+	*packets = nil
+	pm.tickJoinQueue(now)
+	third, err := pm.GetPlayerState(newTestAddr(3).String())
+	assert.Nil(t, err)
+	assert.Equal(t, "third", third.Name)
+	assert.True(t, hasPacket(*packets, "I;"))
+	assert.True(t, hasPacket(*packets, "N;"))
+	_, err = pm.GetPlayerState(newTestAddr(4).String())
+	assert.NotNil(t, err)
+
+	assert.True(t, pm.LeaveJoinQueue(newTestAddr(4).String()))
+	assert.Equal(t, 0, pm.joinQueue.Len())
+}
+
+func TestReservedSlotsAreForAdmins(t *testing.T) {
+	pm, _ := newMatchPlayerManager(t, func(config *Config) {
+		config.MaxPlayers = 2
+		config.ReservedAdminSlots = 1
+		config.AdminHosts = []string{"10.0.0.1"}
+	})
+	now := time.Now().UnixMilli()
+
+	_, err := pm.CreatePlayer(newTestAddr(1), "first", NO_TEAM)
+	assert.Nil(t, err)
+	_, err = pm.CreatePlayer(newTestAddr(2), "second", NO_TEAM)
+	assert.IsType(t, &LoginError{}, err)
+
+	// the admin queues behind the player but takes the reserved slot first
+	_, err = pm.CreatePlayer(&net.UDPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 1}, "admin", NO_TEAM)
+	assert.IsType(t, &LoginError{}, err)
+	pm.tickJoinQueue(now)
+	assert.Len(t, pm.GetAllPlayerStates(nil), 2)
+	assert.Equal(t, 1, pm.joinQueue.Len())
+}
+
+func TestQueuedLoginsExpire(t *testing.T) {
+	pm, _ := newMatchPlayerManager(t, withTwoSlots)
+	fillServer(pm)
+	pm.CreatePlayer(newTestAddr(3), "third", NO_TEAM)
+
+	pm.tickJoinQueue(time.Now().UnixMilli() + JOIN_QUEUE_TIMEOUT_MS)
+	assert.Equal(t, 0, pm.joinQueue.Len())
+}
+
+func TestBotsDontTakeSlots(t *testing.T) {
+	pm, _ := newMatchPlayerManager(t, func(config *Config) {
+		config.MaxPlayers = 1
+	})
+	pm.AddBot(time.Now().UnixMilli())
+
+	_, err := pm.CreatePlayer(newTestAddr(1), "human", NO_TEAM)
+	assert.Nil(t, err)
+}
@@ -0,0 +1,28 @@
+package udp_server
+
+import "fmt"
+
+type KillEvent struct {
+	KillerID int
+	VictimID int
+	Weapon   string
+	Flags    int // KILL_FLAG_* bits
+}
+
+func (ke KillEvent) String() string {
+	return fmt.Sprintf("%d:%d:%s:%d", ke.KillerID, ke.VictimID, ke.Weapon, ke.Flags)
+}
+
+func NewKillEvent(killer PlayerState, victim PlayerState, weapon string) KillEvent {
+	event := KillEvent{
+		KillerID: killer.ID,
+		VictimID: victim.ID,
+		Weapon:   weapon,
+	}
+	if killer.ID == victim.ID {
+		event.Flags |= KILL_FLAG_SUICIDE
+	} else if !killer.IsEnemy(victim) {
+		event.Flags |= KILL_FLAG_TEAM_KILL
+	}
+	return event
+}
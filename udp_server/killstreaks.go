@@ -0,0 +1,98 @@
+package udp_server
+
+import (
+	"fmt"
+	"time"
+)
+
+// StreakReward is handed out when a player reaches Streak kills without dying
+type StreakReward struct {
+	Streak         int
+	BonusScore     int
+	Buff           string // one of the BUFF_* constants, empty for none
+	BuffDurationMs int64
+}
+
+type StreakEvent struct {
+	Event    string // one of the STREAK_EVENT_* constants
+	PlayerID int
+	Count    int
+	OtherID  int // who ended the streak, 0 otherwise
+}
+
+func (se StreakEvent) String() string {
+	return fmt.Sprintf("%s:%d:%d:%d", se.Event, se.PlayerID, se.Count, se.OtherID)
+}
+
+func (ps *PlayerState) HasBuff(buff string, now int64) bool {
+	return ps.Buff == buff && now < ps.BuffUntil
+}
+
+// recordKill advances the killers streak, multikill count and headshot tally, returning the updated state
+func (pm *PlayerManager) recordKill(killer PlayerState, headshot bool) (PlayerState, error) {
+	now := time.Now().UnixMilli()
+	return pm.modifyPlayerState(killer.Addr.String(), func(ps *PlayerState) {
+		ps.Streak++
+		if headshot {
+			ps.Headshots++
+		}
+		if ps.LastKillAt != 0 && now-ps.LastKillAt <= pm.config.MultiKillWindowMs {
+			ps.MultiKill++
+		} else {
+			ps.MultiKill = 1
+		}
+		ps.LastKillAt = now
+	})
+}
+
+// endStreak resets the victims streak, announcing it if it was worth mentioning
+func (pm *PlayerManager) endStreak(victim PlayerState, killer PlayerState) {
+	if victim.Streak >= STREAK_ANNOUNCE_MIN {
+		pm.broadcastReliable(parser.EncodeStreakEvent(StreakEvent{
+			Event:    STREAK_EVENT_ENDED,
+			PlayerID: victim.ID,
+			Count:    victim.Streak,
+			OtherID:  killer.ID,
+		}))
+	}
+	pm.modifyPlayerState(victim.Addr.String(), func(ps *PlayerState) {
+		ps.Streak = 0
+		ps.MultiKill = 0
+		ps.LastKillAt = 0
+	})
+}
+
+// handleKillStreak announces milestones and multikills and hands out rewards
+func (pm *PlayerManager) handleKillStreak(killer PlayerState) {
+	if killer.MultiKill >= 2 {
+		pm.broadcastReliable(parser.EncodeStreakEvent(StreakEvent{
+			Event:    STREAK_EVENT_MULTI_KILL,
+			PlayerID: killer.ID,
+			Count:    killer.MultiKill,
+		}))
+	}
+	for _, reward := range pm.config.StreakRewards {
+		if reward.Streak != killer.Streak {
+			continue
+		}
+		pm.broadcastReliable(parser.EncodeStreakEvent(StreakEvent{
+			Event:    STREAK_EVENT_MILESTONE,
+			PlayerID: killer.ID,
+			Count:    killer.Streak,
+		}))
+		pm.applyStreakReward(killer, reward)
+	}
+}
+
+func (pm *PlayerManager) applyStreakReward(killer PlayerState, reward StreakReward) {
+	if reward.BonusScore != 0 {
+		pm.gameMode.OnBonusScore(pm, killer, reward.BonusScore)
+	}
+	if reward.Buff != "" {
+		buffUntil := time.Now().UnixMilli() + reward.BuffDurationMs
+		pm.modifyPlayerState(killer.Addr.String(), func(ps *PlayerState) {
+			ps.Buff = reward.Buff
+			ps.BuffUntil = buffUntil
+		})
+	}
+}
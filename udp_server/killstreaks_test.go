@@ -0,0 +1,57 @@
+package udp_server
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func killPlayer(pm *PlayerManager, killer PlayerState, victim PlayerState) {
+	for i := 0; i < MAX_HEALTH; i++ {
+		if shootPlayer(pm, killer, victim) != nil {
+			return
+		}
+	}
+}
+
+func TestKillStreakMilestoneAndEnd(t *testing.T) {
+	pm, packets := newMatchPlayerManager(t, func(config *Config) {
+		config.ScoreLimit = 0
+		config.StreakRewards = []StreakReward{{Streak: 3, BonusScore: 2}}
+	})
+
+	killer, _ := pm.CreatePlayer(newTestAddr(1), "killer", NO_TEAM)
+	victim, _ := pm.CreatePlayer(newTestAddr(2), "victim", NO_TEAM)
+	for i := 0; i < 3; i++ {
+		killPlayer(pm, killer, victim)
+		pm.EndSpawnProtection(victim.Addr.String())
+	}
+
+	killerState, _ := pm.GetPlayerState(killer.Addr.String())
+	assert.Equal(t, 3, killerState.Streak)
+	assert.Equal(t, 3, killerState.MultiKill)
+	assert.Equal(t, 3+2, killerState.Score)
+	assert.True(t, hasReliablePacket(*packets, fmt.Sprintf("%s;%s:%d:3:0", STREAK_MESSAGE, STREAK_EVENT_MULTI_KILL, killer.ID)))
+
+	killPlayer(pm, victim, killer)
+	killerState, _ = pm.GetPlayerState(killer.Addr.String())
+	assert.Equal(t, 0, killerState.Streak)
+
+	assert.True(t, hasReliablePacket(*packets, fmt.Sprintf("%s;%s:%d:3:%d", STREAK_MESSAGE, STREAK_EVENT_ENDED, killer.ID, victim.ID)))
+}
+
+func TestDoubleDamageBuff(t *testing.T) {
+	pm, _ := newMatchPlayerManager(t, func(config *Config) {
+		config.StreakRewards = []StreakReward{{Streak: 1, Buff: BUFF_DOUBLE_DAMAGE, BuffDurationMs: 60 * 1000}}
+	})
+
+	killer, _ := pm.CreatePlayer(newTestAddr(1), "killer", NO_TEAM)
+	victim, _ := pm.CreatePlayer(newTestAddr(2), "victim", NO_TEAM)
+	killPlayer(pm, killer, victim)
+	pm.EndSpawnProtection(victim.Addr.String())
+
+	shootPlayer(pm, killer, victim)
+	victimState, _ := pm.GetPlayerState(victim.Addr.String())
+	assert.Equal(t, MAX_HEALTH-2, victimState.Health)
+}
@@ -0,0 +1,90 @@
+package udp_server
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// HandleLobbyRequest applies a players team, loadout or ready choice and sends everyone the new roster.
+// The lobby is only open during warmup, the choices are what the match starts with
+func (pm *PlayerManager) HandleLobbyRequest(addrStr string, request LobbyRequest) error {
+	if phase := pm.match.Phase(); phase != MATCH_PHASE_WARMUP {
+		return fmt.Errorf("client %s: Lobby is closed during %s", addrStr, phase)
+	}
+	var change func(ps *PlayerState)
+	switch request.Action {
+	case LOBBY_ACTION_READY:
+		ready, err := strconv.ParseBool(request.Value)
+		if err != nil {
+			return fmt.Errorf("unable to parse ready flag: %s", err.Error())
+		}
+		change = func(ps *PlayerState) { ps.Ready = ready }
+	case LOBBY_ACTION_TEAM:
+		team, err := strconv.Atoi(request.Value)
+		if err != nil {
+			return fmt.Errorf("unable to parse team: %s", err.Error())
+		}
+		if pm.config.TeamCount == 0 || team < 1 || team > pm.config.TeamCount {
+			return fmt.Errorf("client %s: Cant join team %d in %s", addrStr, team, pm.gameMode.Name())
+		}
+		change = func(ps *PlayerState) { ps.Team = team }
+	case LOBBY_ACTION_LOADOUT:
+		if _, ok := GetWeapon(request.Value); !ok {
+			return fmt.Errorf("client %s: Unknown loadout %s", addrStr, request.Value)
+		}
+		change = func(ps *PlayerState) { ps.Loadout = request.Value }
+	default:
+		return fmt.Errorf("unknown lobby action %s", request.Action)
+	}
+	if _, err := pm.modifyPlayerState(addrStr, change); err != nil {
+		return err
+	}
+	pm.BroadcastLobby()
+	return nil
+}
+
+// BroadcastLobby sends everyone who is in the game with their team, loadout and ready flag
+func (pm *PlayerManager) BroadcastLobby() {
+	pm.broadcastReliable(parser.EncodeLobbyRoster(pm.GetAllPlayerStates(nil)))
+}
+
+// RefreshLobby resends the roster after someone joins or leaves, only while the lobby is open
+func (pm *PlayerManager) RefreshLobby() {
+	if pm.match.Phase() == MATCH_PHASE_WARMUP {
+		pm.BroadcastLobby()
+	}
+}
+
+// canUseWeapon holds players to the loadout they picked once the match is live, warmup is for trying everything
+func (pm *PlayerManager) canUseWeapon(ps PlayerState, weapon string) bool {
+	return pm.match.Phase() != MATCH_PHASE_LIVE || ps.Loadout == weapon
+}
+
+// lobbyReady reports whether enough human players are ready to skip the rest of the warmup countdown
+func (pm *PlayerManager) lobbyReady() bool {
+	if pm.config.LobbyReadyRatio == 0 {
+		return false
+	}
+	humans, ready := 0, 0
+	for _, ps := range pm.GetAllPlayerStates(nil) {
+		if isBotAddr(ps.Addr) {
+			continue
+		}
+		humans++
+		if ps.Ready {
+			ready++
+		}
+	}
+	needed := int(math.Ceil(pm.config.LobbyReadyRatio * float64(humans)))
+	return humans > 0 && ready >= needed
+}
+
+// clearReady takes everyone out of the ready state once the lobby closes
+func (pm *PlayerManager) clearReady() {
+	for _, ps := range pm.GetAllPlayerStates(nil) {
+		pm.modifyPlayerState(ps.Addr.String(), func(ps *PlayerState) {
+			ps.Ready = false
+		})
+	}
+}
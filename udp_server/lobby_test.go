@@ -0,0 +1,108 @@
+package udp_server
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadyPlayersStartTheMatchEarly(t *testing.T) {
+	pm, packets := newMatchPlayerManager(t)
+	first, _ := pm.CreatePlayer(newTestAddr(1), "first", NO_TEAM)
+	second, _ := pm.CreatePlayer(newTestAddr(2), "second", NO_TEAM)
+	pm.Tick(0)
+
+	assert.Nil(t, pm.HandleLobbyRequest(first.Addr.String(), LobbyRequest{Action: LOBBY_ACTION_READY, Value: "1"}))
+	assert.True(t, hasReliablePacket(*packets, "A;1:first:0:rifle:1;2:second:0:rifle:0"))
+	pm.Tick(100)
+	assert.Equal(t, MATCH_PHASE_WARMUP, pm.GetMatch().Phase())
+
+	assert.Nil(t, pm.HandleLobbyRequest(second.Addr.String(), LobbyRequest{Action: LOBBY_ACTION_READY, Value: "1"}))
+	pm.Tick(200)
+	assert.Equal(t, MATCH_PHASE_LIVE, pm.GetMatch().Phase())
+	// the starting roster goes out with everyone unready for the next lobby
+	assert.True(t, hasReliablePacket(*packets, "A;1:first:0:rifle:0;2:second:0:rifle:0"))
+
+	// the lobby closes once the match is live
+	assert.NotNil(t, pm.HandleLobbyRequest(first.Addr.String(), LobbyRequest{Action: LOBBY_ACTION_READY, Value: "1"}))
+}
+
+func TestCountdownStartsTheMatchWithoutEveryoneReady(t *testing.T) {
+	pm, _ := newMatchPlayerManager(t)
+	first, _ := pm.CreatePlayer(newTestAddr(1), "first", NO_TEAM)
+	pm.CreatePlayer(newTestAddr(2), "second", NO_TEAM)
+
+	assert.Nil(t, pm.HandleLobbyRequest(first.Addr.String(), LobbyRequest{Action: LOBBY_ACTION_READY, Value: "1"}))
+	pm.Tick(0)
+	pm.Tick(999)
+	assert.Equal(t, MATCH_PHASE_WARMUP, pm.GetMatch().Phase())
+	pm.Tick(1000)
+	assert.Equal(t, MATCH_PHASE_LIVE, pm.GetMatch().Phase())
+}
+
+func TestLobbyPicksTeamAndLoadout(t *testing.T) {
+	pm, packets := newMatchPlayerManager(t, withTeams)
+	player, _ := pm.CreatePlayer(newTestAddr(1), "player", 1)
+	addrStr := player.Addr.String()
+
+	assert.Nil(t, pm.HandleLobbyRequest(addrStr, LobbyRequest{Action: LOBBY_ACTION_TEAM, Value: "2"}))
+	assert.Nil(t, pm.HandleLobbyRequest(addrStr, LobbyRequest{Action: LOBBY_ACTION_LOADOUT, Value: WEAPON_ROCKET}))
+	assert.True(t, hasReliablePacket(*packets, "A;1:player:2:rocket:0"))
+	playerState, _ := pm.GetPlayerState(addrStr)
+	assert.Equal(t, 2, playerState.Team)
+	assert.Equal(t, WEAPON_ROCKET, playerState.Loadout)
+
+	assert.NotNil(t, pm.HandleLobbyRequest(addrStr, LobbyRequest{Action: LOBBY_ACTION_TEAM, Value: "3"}))
+	assert.NotNil(t, pm.HandleLobbyRequest(addrStr, LobbyRequest{Action: LOBBY_ACTION_LOADOUT, Value: "spoon"}))
+	assert.NotNil(t, pm.HandleLobbyRequest(addrStr, LobbyRequest{Action: "dance", Value: "1"}))
+}
+
+func TestFreeForAllHasNoTeamsToPick(t *testing.T) {
+	pm, _ := newMatchPlayerManager(t)
+	player, _ := pm.CreatePlayer(newTestAddr(1), "player", NO_TEAM)
+
+	assert.NotNil(t, pm.HandleLobbyRequest(player.Addr.String(), LobbyRequest{Action: LOBBY_ACTION_TEAM, Value: "1"}))
+}
+
+func TestLoadoutIsEnforcedOnceLive(t *testing.T) {
+	pm, _ := newMatchPlayerManager(t)
+	shooter, _ := pm.CreatePlayer(newTestAddr(1), "shooter", NO_TEAM)
+	victim, _ := pm.CreatePlayer(newTestAddr(2), "victim", NO_TEAM)
+	assert.Nil(t, pm.HandleLobbyRequest(shooter.Addr.String(), LobbyRequest{Action: LOBBY_ACTION_LOADOUT, Value: WEAPON_ROCKET}))
+	placePlayer(pm, shooter, Position{})
+	placePlayer(pm, victim, Position{z: 10})
+	fire := Fire{Weapon: WEAPON_GRENADE, Origin: Position{y: 1}, Direction: Position{z: 1}}
+
+	// anything goes during warmup
+	_, err := pm.FireProjectile(shooter.Addr.String(), fire, 0)
+	assert.Nil(t, err)
+
+	pm.Tick(0)
+	pm.Tick(1000)
+	assert.Equal(t, MATCH_PHASE_LIVE, pm.GetMatch().Phase())
+	pm.EndSpawnProtection(victim.Addr.String())
+	placePlayer(pm, shooter, Position{})
+	placePlayer(pm, victim, Position{z: 10})
+
+	_, err = pm.FireProjectile(shooter.Addr.String(), fire, 0)
+	assert.NotNil(t, err)
+	shootPlayerIn(pm, shooter, victim, HIT_ZONE_BODY)
+	victimState, _ := pm.GetPlayerState(victim.Addr.String())
+	assert.Equal(t, MAX_HEALTH, victimState.Health)
+
+	fire.Weapon = WEAPON_ROCKET
+	_, err = pm.FireProjectile(shooter.Addr.String(), fire, 0)
+	assert.Nil(t, err)
+}
+
+func TestLeavingUpdatesTheLobby(t *testing.T) {
+	s, received := newRoutingServer(t)
+	stays, leaves := newTestAddr(1), newTestAddr(2)
+	s.processMessage(stays, []byte("L;stays"))
+	s.processMessage(leaves, []byte("L;leaves"))
+
+	// the same roster already went out when stays was alone, so look only at what the leave sends
+	before := len(received(stays))
+	s.processMessage(leaves, []byte("Q;"))
+	assert.True(t, hasReliablePacket(received(stays)[before:], "A;1:stays:0:rifle:0"))
+}
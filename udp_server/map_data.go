@@ -0,0 +1,93 @@
+package udp_server
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+type MapData struct {
+	Name         string       `json:"name"`
+	SpawnPoints  []SpawnPoint `json:"spawnPoints"`
+	Pickups      []PickupDef  `json:"pickups"`
+	Geometry     []Box        `json:"geometry"`     // solid boxes that block shots, projectiles and spawns
+	Bounds       *Box         `json:"bounds"`       // players outside are handled by BoundsAction, nil for no bounds
+	BoundsAction string       `json:"boundsAction"` // one of the BOUNDS_ACTION_* constants, defaults to snap
+	Hazards      []HazardDef  `json:"hazards"`
+}
+
+// DefaultMapData mirrors the old hard-coded spawn area: a single 10x10 zone at y=10
+func DefaultMapData() MapData {
+	return MapData{
+		Name: "default",
+		SpawnPoints: []SpawnPoint{
+			{
+				Name:     "center",
+				Position: Position{x: 5, y: 10, z: 5},
+				Extent:   Position{x: 5, y: 0, z: 5},
+			},
+		},
+	}
+}
+
+func LoadMapData(path string) (MapData, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return MapData{}, fmt.Errorf("error reading map file %s: %s", path, err.Error())
+	}
+	var mapData MapData
+	if err := json.Unmarshal(data, &mapData); err != nil {
+		return MapData{}, fmt.Errorf("error parsing map file %s: %s", path, err.Error())
+	}
+	if len(mapData.SpawnPoints) == 0 {
+		return MapData{}, fmt.Errorf("map %s has no spawn points", path)
+	}
+	for i, solid := range mapData.Geometry {
+		if !solid.IsValid() {
+			return MapData{}, fmt.Errorf("map %s: geometry box %d is not a valid box", path, i)
+		}
+	}
+	geometry := NewMapGeometry(mapData.Geometry)
+	for _, sp := range mapData.SpawnPoints {
+		if geometry.Overlaps(playerBoxAt(sp.Position, 0)) {
+			return MapData{}, fmt.Errorf("map %s: spawn point %s is inside solid geometry", path, sp.Name)
+		}
+	}
+	if err := validateWorld(mapData); err != nil {
+		return MapData{}, fmt.Errorf("map %s: %s", path, err.Error())
+	}
+	for _, pickup := range mapData.Pickups {
+		if err := validatePickupDef(pickup); err != nil {
+			return MapData{}, fmt.Errorf("map %s: %s", path, err.Error())
+		}
+	}
+	return mapData, nil
+}
+
+// Positions in map files are written as [x, y, z]
+func (p *Position) UnmarshalJSON(data []byte) error {
+	var coords [3]float32
+	if err := json.Unmarshal(data, &coords); err != nil {
+		return err
+	}
+	p.x, p.y, p.z = coords[0], coords[1], coords[2]
+	return nil
+}
+
+// Level is everything built from a loaded map, it is replaced as a whole when the map changes
+type Level struct {
+	geometry     *MapGeometry
+	world        *World
+	spawnManager *SpawnManager
+	pickups      *PickupManager
+}
+
+func NewLevel(mapData MapData, entities *EntityRegistry) *Level {
+	geometry := NewMapGeometry(mapData.Geometry)
+	return &Level{
+		geometry:     geometry,
+		world:        NewWorld(mapData),
+		spawnManager: NewSpawnManager(mapData.SpawnPoints, geometry),
+		pickups:      NewPickupManager(entities, mapData.Pickups),
+	}
+}
@@ -0,0 +1,181 @@
+package udp_server
+
+import (
+	"sort"
+	"sync"
+)
+
+// Match tracks the phase of the current match, it is advanced by PlayerManager.Tick
+type Match struct {
+	mu          sync.Mutex
+	phase       string
+	phaseEndsAt int64 // 0 while the phase has no deadline
+	lastClockAt int64
+}
+
+func NewMatch() *Match {
+	return &Match{phase: MATCH_PHASE_WARMUP}
+}
+
+func (m *Match) Phase() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.phase
+}
+
+// RemainingMs is the time left in the current phase, -1 when there is no deadline
+func (m *Match) RemainingMs(now int64) int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.remainingMs(now)
+}
+
+func (m *Match) remainingMs(now int64) int64 {
+	if m.phaseEndsAt == 0 {
+		return -1
+	}
+	if now > m.phaseEndsAt {
+		return 0
+	}
+	return m.phaseEndsAt - now
+}
+
+func (m *Match) setPhase(phase string, endsAt int64) {
+	m.phase = phase
+	m.phaseEndsAt = endsAt
+	m.lastClockAt = 0
+}
+
+func (pm *PlayerManager) tickMatch(now int64) {
+	m := pm.match
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	switch m.phase {
+	case MATCH_PHASE_WARMUP:
+		if len(pm.GetAllPlayerStates(nil)) < pm.config.MinPlayersToStart {
+			if m.phaseEndsAt != 0 {
+				m.setPhase(MATCH_PHASE_WARMUP, 0)
+				pm.broadcastMatchPhase(now)
+			}
+			return
+		}
+		if m.phaseEndsAt == 0 {
+			m.setPhase(MATCH_PHASE_WARMUP, now+pm.config.WarmupMs)
+			pm.broadcastMatchPhase(now)
+		}
+		if now >= m.phaseEndsAt || pm.lobbyReady() {
+			pm.startMatch(now)
+		}
+	case MATCH_PHASE_LIVE:
+		timeUp := m.phaseEndsAt != 0 && now >= m.phaseEndsAt
+		winner, over := pm.gameMode.CheckWinCondition(pm, timeUp)
+		if over || timeUp {
+			pm.endMatch(now, winner)
+		}
+	case MATCH_PHASE_POST_MATCH:
+		if now >= m.phaseEndsAt {
+			pm.ResetStats()
+			pm.votes.ClearBans()
+			m.setPhase(MATCH_PHASE_WARMUP, 0)
+			pm.broadcastMatchPhase(now)
+			pm.BroadcastScores()
+		}
+	}
+
+	if now-m.lastClockAt >= MATCH_CLOCK_SYNC_MS {
+		pm.broadcastMatchPhase(now)
+	}
+}
+
+// startMatch must be called with the match lock held
+func (pm *PlayerManager) startMatch(now int64) {
+	logger.info("Match started")
+	for _, ps := range pm.GetAllPlayerStates(nil) {
+		pm.RespawnPlayer(ps.Addr.String(), now)
+	}
+	// reset after respawning so the respawns dont count as deaths
+	pm.ResetStats()
+	pm.clearReady()
+	for _, ps := range pm.GetAllPlayerStates(nil) {
+		pm.send(ps.Addr, parser.EncodePlayerResetMessage(ps))
+	}
+	endsAt := int64(0)
+	if pm.config.TimeLimitMs > 0 {
+		endsAt = now + pm.config.TimeLimitMs
+	}
+	pm.match.setPhase(MATCH_PHASE_LIVE, endsAt)
+	pm.broadcastMatchPhase(now)
+	pm.BroadcastScores()
+	// the teams and loadouts everyone starts with
+	pm.BroadcastLobby()
+}
+
+// RestartMatch throws away the current match and goes back to warmup with everyone respawned
+func (pm *PlayerManager) RestartMatch(now int64) {
+	pm.match.mu.Lock()
+	defer pm.match.mu.Unlock()
+
+	logger.info("Match restarted")
+	for _, ps := range pm.GetAllPlayerStates(nil) {
+		pm.RespawnPlayer(ps.Addr.String(), now)
+	}
+	pm.ResetStats()
+	pm.votes.ClearBans()
+	for _, ps := range pm.GetAllPlayerStates(nil) {
+		pm.send(ps.Addr, parser.EncodePlayerResetMessage(ps))
+	}
+	pm.match.setPhase(MATCH_PHASE_WARMUP, 0)
+	pm.broadcastMatchPhase(now)
+	pm.BroadcastScores()
+}
+
+// endMatch must be called with the match lock held
+func (pm *PlayerManager) endMatch(now int64, winner int) {
+	logger.info("Match over, winner %d", winner)
+	pm.match.setPhase(MATCH_PHASE_POST_MATCH, now+pm.config.PostMatchMs)
+	pm.broadcastMatchPhase(now)
+	pm.broadcast(parser.EncodeMatchResults(winner, pm.GetStandings()))
+	pm.BroadcastScores()
+}
+
+// broadcastMatchPhase must be called with the match lock held
+func (pm *PlayerManager) broadcastMatchPhase(now int64) {
+	pm.match.lastClockAt = now
+	pm.broadcast(parser.EncodeMatchPhase(pm.match.phase, pm.match.remainingMs(now)))
+}
+
+// AcceptsDamage is false once the match is decided
+func (m *Match) AcceptsDamage() bool {
+	return m.Phase() != MATCH_PHASE_POST_MATCH
+}
+
+// GetStandings returns all players sorted by score, fewest deaths first on ties
+func (pm *PlayerManager) GetStandings() []PlayerState {
+	standings := pm.GetAllPlayerStates(nil)
+	sort.SliceStable(standings, func(i, j int) bool {
+		if standings[i].Score != standings[j].Score {
+			return standings[i].Score > standings[j].Score
+		}
+		return standings[i].Deaths < standings[j].Deaths
+	})
+	return standings
+}
+
+func (pm *PlayerManager) ResetStats() {
+	for _, ps := range pm.GetAllPlayerStates(nil) {
+		pm.modifyPlayerState(ps.Addr.String(), func(ps *PlayerState) {
+			ps.Score = 0
+			ps.Deaths = 0
+			ps.Assists = 0
+			ps.Headshots = 0
+			ps.Streak = 0
+			ps.MultiKill = 0
+			ps.LastKillAt = 0
+			ps.Buff = ""
+		})
+	}
+	pm.teamScoresMu.Lock()
+	pm.teamScores = make(map[int]int)
+	pm.teamScoresMu.Unlock()
+}
@@ -0,0 +1,124 @@
+package udp_server
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestConfig is the config tests run with, options change it for a single test
+func newTestConfig(options ...func(config *Config)) Config {
+	config := DefaultConfig()
+	config.SpawnProtectionMs = 0
+	config.WarmupMs = 1000
+	config.TimeLimitMs = 5000
+	config.ScoreLimit = 1
+	config.PostMatchMs = 1000
+	// tests teleport players around
+	config.WalkSpeed = 0
+	config.BotTargetPlayers = 0
+	for _, option := range options {
+		option(&config)
+	}
+	return config
+}
+
+// withTeams switches the test config to team deathmatch
+func withTeams(config *Config) {
+	config.Mode = GAME_MODE_TEAM_DEATHMATCH
+	config.TeamCount = 2
+}
+
+// newMatchPlayerManager applies the test config through SetConfig so it is validated like a real one
+func newMatchPlayerManager(t *testing.T, options ...func(config *Config)) (*PlayerManager, *[]string) {
+	pm := NewPlayerManager()
+	assert.Nil(t, pm.SetConfig(newTestConfig(options...)))
+
+	packets := []string{}
+	pm.SetPacketSender(func(addr *net.UDPAddr, packet string) {
+		packets = append(packets, packet)
+	})
+	return pm, &packets
+}
+
+func hasPacket(packets []string, prefix string) bool {
+	for _, packet := range packets {
+		if strings.HasPrefix(packet, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasReliablePacket looks for packet inside a Y wrapper with any sequence number
+func hasReliablePacket(packets []string, packet string) bool {
+	for _, p := range packets {
+		msg, err := parser.ParseMessage([]byte(p))
+		if err != nil || msg.messageType != RELIABLE_MESSAGE {
+			continue
+		}
+		if strings.HasSuffix(p, ";"+packet) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestMatchLifecycle(t *testing.T) {
+	pm, packets := newMatchPlayerManager(t)
+	shooter, _ := pm.CreatePlayer(newTestAddr(1), "shooter", NO_TEAM)
+	victim, _ := pm.CreatePlayer(newTestAddr(2), "victim", NO_TEAM)
+
+	pm.Tick(0)
+	assert.Equal(t, MATCH_PHASE_WARMUP, pm.GetMatch().Phase())
+	assert.Equal(t, int64(1000), pm.GetMatch().RemainingMs(0))
+
+	pm.Tick(1000)
+	assert.Equal(t, MATCH_PHASE_LIVE, pm.GetMatch().Phase())
+	assert.True(t, hasPacket(*packets, "M;live:5000"))
+
+	// everyone respawns protected when the match starts
+	pm.EndSpawnProtection(victim.Addr.String())
+	for i := 0; i < MAX_HEALTH; i++ {
+		shootPlayer(pm, shooter, victim)
+	}
+	pm.modifyPlayerState(shooter.Addr.String(), func(ps *PlayerState) {
+		ps.Headshots = 1
+	})
+	pm.Tick(1100)
+	assert.Equal(t, MATCH_PHASE_POST_MATCH, pm.GetMatch().Phase())
+	assert.True(t, hasPacket(*packets, fmt.Sprintf("E;%d;", shooter.ID)))
+	assert.False(t, pm.GetMatch().AcceptsDamage())
+
+	pm.Tick(2100)
+	assert.Equal(t, MATCH_PHASE_WARMUP, pm.GetMatch().Phase())
+	shooterState, _ := pm.GetPlayerState(shooter.Addr.String())
+	assert.Equal(t, 0, shooterState.Score)
+	assert.Equal(t, 0, shooterState.Headshots)
+}
+
+func TestMatchEndsOnTimeLimit(t *testing.T) {
+	pm, _ := newMatchPlayerManager(t)
+	pm.CreatePlayer(newTestAddr(1), "p1", NO_TEAM)
+	pm.CreatePlayer(newTestAddr(2), "p2", NO_TEAM)
+
+	pm.Tick(0)
+	pm.Tick(1000)
+	pm.Tick(5999)
+	assert.Equal(t, MATCH_PHASE_LIVE, pm.GetMatch().Phase())
+	pm.Tick(6000)
+	assert.Equal(t, MATCH_PHASE_POST_MATCH, pm.GetMatch().Phase())
+}
+
+func TestWarmupWaitsForPlayers(t *testing.T) {
+	pm, _ := newMatchPlayerManager(t)
+	pm.CreatePlayer(newTestAddr(1), "p1", NO_TEAM)
+
+	pm.Tick(0)
+	pm.Tick(10000)
+	assert.Equal(t, MATCH_PHASE_WARMUP, pm.GetMatch().Phase())
+	assert.Equal(t, int64(-1), pm.GetMatch().RemainingMs(10000))
+}
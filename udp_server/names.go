@@ -0,0 +1,79 @@
+package udp_server
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// LoginError is a login refused for a reason the client is told about with D
+type LoginError struct {
+	Reason string // one of the LOGIN_REJECT_* constants
+	Detail string
+}
+
+func (le *LoginError) Error() string {
+	return fmt.Sprintf("login rejected: %s %s", le.Reason, le.Detail)
+}
+
+func (le *LoginError) String() string {
+	return fmt.Sprintf("%s:%s", le.Reason, le.Detail)
+}
+
+// checkName cleans up the whitespace in a requested name and checks it against the name rules
+func (c Config) checkName(name string) (string, error) {
+	name = strings.Join(strings.Fields(name), " ")
+	if length := len([]rune(name)); length < c.NameMinLength || length > c.NameMaxLength {
+		return "", &LoginError{Reason: LOGIN_REJECT_NAME_LENGTH, Detail: fmt.Sprintf("%d-%d", c.NameMinLength, c.NameMaxLength)}
+	}
+	for _, r := range name {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			continue
+		}
+		if strings.ContainsRune(NAME_RESERVED_SYMBOLS, r) || !strings.ContainsRune(c.NameAllowedSymbols, r) {
+			return "", &LoginError{Reason: LOGIN_REJECT_NAME_CHARS, Detail: c.NameAllowedSymbols}
+		}
+	}
+	lowered := strings.ToLower(name)
+	for _, word := range c.NameBlocklist {
+		if word != "" && strings.Contains(lowered, strings.ToLower(word)) {
+			return "", &LoginError{Reason: LOGIN_REJECT_NAME_BLOCKED}
+		}
+	}
+	return name, nil
+}
+
+// uniqueName adds a counter to names already used by a player or spectator, ignoring case.
+// The name is cut short when the counter would go over the length limit. Callers must hold joinMu
+func (pm *PlayerManager) uniqueName(name string) string {
+	taken := make(map[string]bool)
+	for _, ps := range pm.GetAllPlayerStates(nil) {
+		taken[strings.ToLower(ps.Name)] = true
+	}
+	for _, spectator := range pm.GetSpectators() {
+		taken[strings.ToLower(spectator.Name)] = true
+	}
+	if !taken[strings.ToLower(name)] {
+		return name
+	}
+	for count := 2; ; count++ {
+		base := []rune(name)
+		suffixLength := len([]rune(fmt.Sprintf(NAME_DUPLICATE_FORMAT, "", count)))
+		if keep := pm.config.NameMaxLength - suffixLength; len(base) > keep && keep > 0 {
+			base = base[:keep]
+		}
+		candidate := fmt.Sprintf(NAME_DUPLICATE_FORMAT, string(base), count)
+		if !taken[strings.ToLower(candidate)] {
+			return candidate
+		}
+	}
+}
+
+// resolveName turns a requested name into the one the player gets, callers must hold joinMu
+func (pm *PlayerManager) resolveName(requested string) (string, error) {
+	name, err := pm.config.checkName(requested)
+	if err != nil {
+		return "", err
+	}
+	return pm.uniqueName(name), nil
+}
@@ -0,0 +1,57 @@
+package udp_server
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNameRules(t *testing.T) {
+	config := DefaultConfig()
+	config.NameBlocklist = []string{"badword"}
+
+	name, err := config.checkName("  Ace   of\tSpades ")
+	assert.Nil(t, err)
+	assert.Equal(t, "Ace of Spades", name)
+
+	rejections := map[string]string{
+		"":                        LOGIN_REJECT_NAME_LENGTH,
+		"a":                       LOGIN_REJECT_NAME_LENGTH,
+		"aVeryLongNameForAPlayer": LOGIN_REJECT_NAME_LENGTH,
+		"semi;colon":              LOGIN_REJECT_NAME_CHARS,
+		"com,ma":                  LOGIN_REJECT_NAME_CHARS,
+		"star*":                   LOGIN_REJECT_NAME_CHARS,
+		"xXBadWordXx":             LOGIN_REJECT_NAME_BLOCKED,
+	}
+	for requested, reason := range rejections {
+		_, err := config.checkName(requested)
+		loginErr, ok := err.(*LoginError)
+		if assert.True(t, ok, requested) {
+			assert.Equal(t, reason, loginErr.Reason, requested)
+		}
+	}
+}
+
+func TestDuplicateNamesGetSuffixes(t *testing.T) {
+	pm, _ := newMatchPlayerManager(t)
+	first, _ := pm.CreatePlayer(newTestAddr(1), "Ace", NO_TEAM)
+	second, _ := pm.CreatePlayer(newTestAddr(2), "ace", NO_TEAM)
+	spectator, _ := pm.CreateSpectator(newTestAddr(3), "ACE")
+	assert.Equal(t, "Ace", first.Name)
+	assert.Equal(t, "ace(2)", second.Name)
+	assert.Equal(t, "ACE(3)", spectator.Name)
+
+	// the suffix fits inside the length limit
+	long, _ := pm.CreatePlayer(newTestAddr(4), "SixteenCharsLong", NO_TEAM)
+	longer, _ := pm.CreatePlayer(newTestAddr(5), "SixteenCharsLong", NO_TEAM)
+	assert.Equal(t, "SixteenCharsLong", long.Name)
+	assert.Equal(t, "SixteenCharsL(2)", longer.Name)
+}
+
+func TestInvalidNameIsRejected(t *testing.T) {
+	pm, _ := newMatchPlayerManager(t)
+	_, err := pm.CreatePlayer(newTestAddr(1), "no;pe", NO_TEAM)
+	assert.IsType(t, &LoginError{}, err)
+	assert.Equal(t, "D;name_chars: _-.", parser.EncodeLoginRejected(err.(*LoginError)))
+	assert.Empty(t, pm.GetAllPlayerStates(nil))
+}
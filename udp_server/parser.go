@@ -2,6 +2,7 @@ package udp_server
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 )
 
@@ -35,18 +36,228 @@ func (p *Parser) ParseMessage(data []byte) (message, error) {
 }
 
 func (p *Parser) ParsePlayerState(newStateStr string) (PlayerState, error) {
-	// newStateStr = "0.000,0.000,0.000:0.000:123123441"
+	// newStateStr = "0.000,0.000,0.000:0.000:123123441" with yaw only, or
+	// "0.000,0.000,0.000:90.000,10.000:123123441:1.000,0.000,0.000:45.000,0.000:3" with pitch, velocity, angular velocity and actions
 	var ps PlayerState
-	_, err := fmt.Sscanf(newStateStr, "%f,%f,%f:%f:%d", &ps.Position.x, &ps.Position.y, &ps.Position.z, &ps.Rotation, &ps.LastUpdatedAt)
+	chunks := strings.Split(newStateStr, ":")
+	if len(chunks) < 3 {
+		return PlayerState{}, fmt.Errorf("missing position, rotation or timestamp")
+	}
+	if _, err := fmt.Sscanf(chunks[0], "%f,%f,%f", &ps.Position.x, &ps.Position.y, &ps.Position.z); err != nil {
+		return PlayerState{}, fmt.Errorf("unable to parse position: %s", err.Error())
+	}
+	rotation, err := p.parseOrientation(chunks[1])
+	if err != nil {
+		return PlayerState{}, fmt.Errorf("unable to parse rotation: %s", err.Error())
+	}
+	ps.Rotation = rotation
+	if ps.LastUpdatedAt, err = strconv.ParseInt(chunks[2], 10, 64); err != nil {
+		return PlayerState{}, fmt.Errorf("unable to parse timestamp: %s", err.Error())
+	}
+	if len(chunks) > 3 {
+		if _, err := fmt.Sscanf(chunks[3], "%f,%f,%f", &ps.Velocity.x, &ps.Velocity.y, &ps.Velocity.z); err != nil {
+			return PlayerState{}, fmt.Errorf("unable to parse velocity: %s", err.Error())
+		}
+	}
+	if len(chunks) > 4 {
+		if ps.AngularVelocity, err = p.parseOrientation(chunks[4]); err != nil {
+			return PlayerState{}, fmt.Errorf("unable to parse angular velocity: %s", err.Error())
+		}
+	}
+	if len(chunks) > 5 {
+		if ps.Actions, err = strconv.Atoi(chunks[5]); err != nil {
+			return PlayerState{}, fmt.Errorf("unable to parse actions: %s", err.Error())
+		}
+	}
+	return ps, nil
+}
+
+// parseOrientation reads "yaw,pitch" or a bare yaw from older clients
+func (p *Parser) parseOrientation(data string) (Orientation, error) {
+	var o Orientation
+	if !strings.Contains(data, ",") {
+		_, err := fmt.Sscanf(data, "%f", &o.Yaw)
+		return o, err
+	}
+	_, err := fmt.Sscanf(data, "%f,%f", &o.Yaw, &o.Pitch)
+	return o, err
+}
+
+type Shot struct {
+	HitPlayerID   int
+	LastUpdatedAt int64
+	Weapon        string
+	Zone          string // one of the HIT_ZONE_* constants
+}
+
+func (p *Parser) ParseShotMessage(shotData string) (Shot, error) {
+	// shotData = "2:123123441", "2:123123441:rifle" or "2:123123441:rifle:head"
+	chunks := strings.Split(shotData, ":")
+	if len(chunks) < 2 {
+		return Shot{}, fmt.Errorf("missing player ID or timestamp")
+	}
+	hitPlayerID, err := strconv.Atoi(chunks[0])
+	if err != nil {
+		return Shot{}, fmt.Errorf("unable to parse player ID: %s", err.Error())
+	}
+	lastUpdatedAt, err := strconv.ParseInt(chunks[1], 10, 64)
+	if err != nil {
+		return Shot{}, fmt.Errorf("unable to parse timestamp: %s", err.Error())
+	}
+	shot := Shot{HitPlayerID: hitPlayerID, LastUpdatedAt: lastUpdatedAt, Weapon: WEAPON_DEFAULT, Zone: HIT_ZONE_BODY}
+	if len(chunks) > 2 && chunks[2] != "" {
+		shot.Weapon = chunks[2]
+	}
+	if len(chunks) > 3 && chunks[3] != "" {
+		if _, ok := ZoneBox(Position{}, chunks[3], 0); !ok {
+			return Shot{}, fmt.Errorf("unknown hit zone %s", chunks[3])
+		}
+		shot.Zone = chunks[3]
+	}
+	return shot, nil
+}
 
-	return ps, err
+type Fire struct {
+	LastUpdatedAt int64
+	Weapon        string
+	Origin        Position
+	Direction     Position
 }
 
-func (p *Parser) ParseLoginMessage(loginData string) string {
-	return loginData
+func (p *Parser) ParseFireMessage(fireData string) (Fire, error) {
+	// fireData = "123123441" or "123123441:rocket:0.000,1.600,0.000:0.000,0.000,1.000"
+	chunks := strings.Split(fireData, ":")
+	lastUpdatedAt, err := strconv.ParseInt(chunks[0], 10, 64)
+	if err != nil {
+		return Fire{}, fmt.Errorf("unable to parse timestamp: %s", err.Error())
+	}
+	fire := Fire{LastUpdatedAt: lastUpdatedAt, Weapon: WEAPON_DEFAULT}
+	if len(chunks) < 2 || chunks[1] == "" {
+		return fire, nil
+	}
+	fire.Weapon = chunks[1]
+	if len(chunks) < 4 {
+		return Fire{}, fmt.Errorf("missing origin or direction")
+	}
+	if _, err := fmt.Sscanf(chunks[2], "%f,%f,%f", &fire.Origin.x, &fire.Origin.y, &fire.Origin.z); err != nil {
+		return Fire{}, fmt.Errorf("unable to parse origin: %s", err.Error())
+	}
+	if _, err := fmt.Sscanf(chunks[3], "%f,%f,%f", &fire.Direction.x, &fire.Direction.y, &fire.Direction.z); err != nil {
+		return Fire{}, fmt.Errorf("unable to parse direction: %s", err.Error())
+	}
+	return fire, nil
+}
+
+func (p *Parser) ParseChatMessage(chatData string) (ChatMessage, error) {
+	// chatData = "all:0:hello", "team:0:push mid" or "whisper:3:hi", the text may contain colons
+	chunks := strings.SplitN(chatData, ":", 3)
+	if len(chunks) < 3 {
+		return ChatMessage{}, fmt.Errorf("missing scope, target or text")
+	}
+	targetID, err := strconv.Atoi(chunks[1])
+	if err != nil {
+		return ChatMessage{}, fmt.Errorf("unable to parse target ID: %s", err.Error())
+	}
+	return ChatMessage{Scope: chunks[0], TargetID: targetID, Text: chunks[2]}, nil
 }
 
-func (p *Parser) EncodePlayerStatesForBroadcast(playerStates []PlayerState) string {
+func (p *Parser) ParseMuteMessage(muteData string) (int, bool, error) {
+	// muteData = "3:1" or "3:0"
+	chunks := strings.Split(muteData, ":")
+	if len(chunks) < 2 {
+		return 0, false, fmt.Errorf("missing player ID or muted flag")
+	}
+	playerID, err := strconv.Atoi(chunks[0])
+	if err != nil {
+		return 0, false, fmt.Errorf("unable to parse player ID: %s", err.Error())
+	}
+	return playerID, chunks[1] == "1", nil
+}
+
+type VoteRequest struct {
+	Type   string // empty when casting a ballot
+	Arg    string
+	Ballot bool
+}
+
+func (p *Parser) ParseVoteMessage(voteData string) (VoteRequest, error) {
+	// voteData = "yes", "no", "kick:3", "map:arena" or "restart:"
+	switch voteData {
+	case VOTE_YES:
+		return VoteRequest{Ballot: true}, nil
+	case VOTE_NO:
+		return VoteRequest{Ballot: false}, nil
+	}
+	chunks := strings.SplitN(voteData, ":", 2)
+	if chunks[0] == "" {
+		return VoteRequest{}, fmt.Errorf("missing vote type")
+	}
+	request := VoteRequest{Type: chunks[0]}
+	if len(chunks) > 1 {
+		request.Arg = chunks[1]
+	}
+	return request, nil
+}
+
+type LobbyRequest struct {
+	Action string // one of the LOBBY_ACTION_* constants
+	Value  string
+}
+
+func (p *Parser) ParseLobbyMessage(lobbyData string) (LobbyRequest, error) {
+	// lobbyData = "ready:1", "team:2" or "loadout:rocket"
+	chunks := strings.SplitN(lobbyData, ":", 2)
+	if len(chunks) != 2 || chunks[0] == "" {
+		return LobbyRequest{}, fmt.Errorf("lobby message needs an action and a value")
+	}
+	return LobbyRequest{Action: chunks[0], Value: chunks[1]}, nil
+}
+
+type Login struct {
+	Name     string
+	Team     int
+	Spectate bool
+	Room     string // empty for the default room
+}
+
+func (p *Parser) ParseLoginMessage(loginData string) Login {
+	// loginData = "name", "name:2", "name::spectate" or "name:2::arena"
+	chunks := strings.Split(loginData, ":")
+	login := Login{Name: chunks[0], Team: NO_TEAM}
+	if len(chunks) > 1 {
+		if team, err := strconv.Atoi(chunks[1]); err == nil {
+			login.Team = team
+		}
+	}
+	if len(chunks) > 2 {
+		login.Spectate = chunks[2] == LOGIN_MODE_SPECTATE
+	}
+	if len(chunks) > 3 {
+		login.Room = chunks[3]
+	}
+	return login
+}
+
+// ParseCameraMessage reads "free", "next" or "follow:{ID}"
+func (p *Parser) ParseCameraMessage(cameraData string) (string, int, error) {
+	chunks := strings.Split(cameraData, ":")
+	switch chunks[0] {
+	case CAMERA_MODE_FREE, CAMERA_NEXT:
+		return chunks[0], 0, nil
+	case CAMERA_MODE_FOLLOW:
+		if len(chunks) < 2 {
+			return "", 0, fmt.Errorf("missing player ID to follow")
+		}
+		followID, err := strconv.Atoi(chunks[1])
+		if err != nil {
+			return "", 0, fmt.Errorf("unable to parse player ID: %s", err.Error())
+		}
+		return CAMERA_MODE_FOLLOW, followID, nil
+	}
+	return "", 0, fmt.Errorf("unknown camera mode %s", chunks[0])
+}
+
+func (p *Parser) EncodePlayerStatesForBroadcast(playerStates []PlayerState, entities []Entity) string {
 	if len(playerStates) < 2 {
 		return ""
 	}
@@ -56,12 +267,15 @@ func (p *Parser) EncodePlayerStatesForBroadcast(playerStates []PlayerState) stri
 	for _, ps := range playerStates {
 		strBuilder.WriteString(fmt.Sprintf(";%s", ps.String()))
 	}
+	p.encodeEntities(&strBuilder, entities)
 	return strBuilder.String()
 }
 
 func (p *Parser) EncodePlayerStatesForInit(
 	newPlayerState PlayerState,
 	existingPlayersState []PlayerState,
+	entities []Entity,
+	chatHistory []ChatMessage,
 ) string {
 	strBuilder := strings.Builder{}
 
@@ -69,6 +283,34 @@ func (p *Parser) EncodePlayerStatesForInit(
 	for _, ps := range existingPlayersState {
 		strBuilder.WriteString(fmt.Sprintf(";%s", ps.String()))
 	}
+	p.encodeEntities(&strBuilder, entities)
+	p.encodeChatHistory(&strBuilder, chatHistory)
+	return strBuilder.String()
+}
+
+// encodeChatHistory appends the recent chat to an init message
+func (p *Parser) encodeChatHistory(strBuilder *strings.Builder, chatHistory []ChatMessage) {
+	for _, message := range chatHistory {
+		strBuilder.WriteString(fmt.Sprintf(";%s%s", CHAT_HISTORY_PREFIX, message.String()))
+	}
+}
+
+// encodeEntities appends the non player entities of a snapshot
+func (p *Parser) encodeEntities(strBuilder *strings.Builder, entities []Entity) {
+	for _, entity := range entities {
+		strBuilder.WriteString(fmt.Sprintf(";%s", entity.SnapshotString()))
+	}
+}
+
+// EncodeSpectatorInit is the I message for spectators, without a state of their own
+func (p *Parser) EncodeSpectatorInit(playerStates []PlayerState, entities []Entity, chatHistory []ChatMessage) string {
+	strBuilder := strings.Builder{}
+	strBuilder.WriteString(SPECTATOR_INIT_MESSAGE)
+	for _, ps := range playerStates {
+		strBuilder.WriteString(fmt.Sprintf(";%s", ps.String()))
+	}
+	p.encodeEntities(&strBuilder, entities)
+	p.encodeChatHistory(&strBuilder, chatHistory)
 	return strBuilder.String()
 }
 
@@ -78,8 +320,8 @@ func (p *Parser) EncodePlayerStateForInit(
 	return fmt.Sprintf("%s;%s", NEW_PLAYER_MESSAGE, newPlayerState.String())
 }
 
-func (p *Parser) EncodePlayerResetMessage() string {
-	return fmt.Sprintf("%s;%s:%.3f:%d", PLAYER_RESET_MESSAGE, RandomPosition().String(), 0.0, MAX_HEALTH)
+func (p *Parser) EncodePlayerResetMessage(ps PlayerState) string {
+	return fmt.Sprintf("%s;%s:%s:%d:%d", PLAYER_RESET_MESSAGE, ps.Position.String(), ps.Rotation.String(), ps.Health, ps.Armor)
 }
 
 func (p *Parser) EncodePlayerScores(playerStates []PlayerState) string {
@@ -91,3 +333,87 @@ func (p *Parser) EncodePlayerScores(playerStates []PlayerState) string {
 	}
 	return strBuilder.String()
 }
+
+func (p *Parser) EncodeTeamScores(teamScores []TeamScore) string {
+	strBuilder := strings.Builder{}
+	strBuilder.WriteString(TEAM_SCORES_MESSAGE)
+
+	for _, ts := range teamScores {
+		strBuilder.WriteString(fmt.Sprintf(";%s", ts.String()))
+	}
+	return strBuilder.String()
+}
+
+func (p *Parser) EncodePlayerLeaveMessage(playerID int) string {
+	return fmt.Sprintf("%s;%d", PLAYER_LEAVE_MESSAGE, playerID)
+}
+
+func (p *Parser) EncodeMatchPhase(phase string, remainingMs int64) string {
+	return fmt.Sprintf("%s;%s:%d", MATCH_PHASE_MESSAGE, phase, remainingMs)
+}
+
+func (p *Parser) EncodeMatchResults(winner int, standings []PlayerState) string {
+	strBuilder := strings.Builder{}
+	strBuilder.WriteString(fmt.Sprintf("%s;%d", MATCH_RESULTS_MESSAGE, winner))
+
+	for _, ps := range standings {
+		strBuilder.WriteString(fmt.Sprintf(";%s", ps.ScoreString()))
+	}
+	return strBuilder.String()
+}
+
+func (p *Parser) EncodeKillEvent(event KillEvent) string {
+	return fmt.Sprintf("%s;%s", KILL_MESSAGE, event.String())
+}
+
+func (p *Parser) EncodeStreakEvent(event StreakEvent) string {
+	return fmt.Sprintf("%s;%s", STREAK_MESSAGE, event.String())
+}
+
+func (p *Parser) EncodePickupSpawns(pickups []Pickup) string {
+	strBuilder := strings.Builder{}
+	strBuilder.WriteString(PICKUP_SPAWN_MESSAGE)
+
+	for _, pickup := range pickups {
+		strBuilder.WriteString(fmt.Sprintf(";%s", pickup.String()))
+	}
+	return strBuilder.String()
+}
+
+func (p *Parser) EncodePickupCollection(collection PickupCollection) string {
+	return fmt.Sprintf("%s;%s", PICKUP_COLLECT_MESSAGE, collection.String())
+}
+
+func (p *Parser) EncodeProjectileSpawn(spawn ProjectileSpawn) string {
+	return fmt.Sprintf("%s;%s", PROJECTILE_SPAWN_MESSAGE, spawn.String())
+}
+
+func (p *Parser) EncodeProjectileImpact(impact ProjectileImpact) string {
+	return fmt.Sprintf("%s;%s", PROJECTILE_IMPACT_MESSAGE, impact.String())
+}
+
+func (p *Parser) EncodeSpectatorCamera(spectator Spectator) string {
+	return fmt.Sprintf("%s;%s:%d", SPECTATOR_CAMERA_MESSAGE, spectator.Mode, spectator.FollowID)
+}
+
+func (p *Parser) EncodeChatMessage(message ChatMessage) string {
+	return fmt.Sprintf("%s;%s", CHAT_MESSAGE, message.String())
+}
+
+func (p *Parser) EncodeLoginRejected(loginErr *LoginError) string {
+	return fmt.Sprintf("%s;%s", LOGIN_REJECTED_MESSAGE, loginErr.String())
+}
+
+func (p *Parser) EncodeVoteStatus(status VoteStatus) string {
+	return fmt.Sprintf("%s;%s", VOTE_MESSAGE, status.String())
+}
+
+func (p *Parser) EncodeLobbyRoster(playerStates []PlayerState) string {
+	strBuilder := strings.Builder{}
+	strBuilder.WriteString(LOBBY_MESSAGE)
+
+	for _, ps := range playerStates {
+		strBuilder.WriteString(fmt.Sprintf(";%s", ps.LobbyString()))
+	}
+	return strBuilder.String()
+}
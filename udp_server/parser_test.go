@@ -0,0 +1,135 @@
+package udp_server
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLoginMessage(t *testing.T) {
+	login := parser.ParseLoginMessage("Atharv")
+	assert.Equal(t, "Atharv", login.Name)
+	assert.Equal(t, NO_TEAM, login.Team)
+	assert.False(t, login.Spectate)
+
+	login = parser.ParseLoginMessage("Atharv:2")
+	assert.Equal(t, "Atharv", login.Name)
+	assert.Equal(t, 2, login.Team)
+
+	login = parser.ParseLoginMessage("Atharv::spectate")
+	assert.Equal(t, "Atharv", login.Name)
+	assert.Equal(t, NO_TEAM, login.Team)
+	assert.True(t, login.Spectate)
+
+	login = parser.ParseLoginMessage("Atharv:2::arena")
+	assert.Equal(t, 2, login.Team)
+	assert.False(t, login.Spectate)
+	assert.Equal(t, "arena", login.Room)
+}
+
+func TestParseCameraMessage(t *testing.T) {
+	mode, followID, err := parser.ParseCameraMessage("follow:3")
+	assert.Nil(t, err)
+	assert.Equal(t, CAMERA_MODE_FOLLOW, mode)
+	assert.Equal(t, 3, followID)
+
+	mode, _, err = parser.ParseCameraMessage("next")
+	assert.Nil(t, err)
+	assert.Equal(t, CAMERA_NEXT, mode)
+
+	_, _, err = parser.ParseCameraMessage("follow")
+	assert.NotNil(t, err)
+	_, _, err = parser.ParseCameraMessage("orbit")
+	assert.NotNil(t, err)
+}
+
+func TestParseChatMessage(t *testing.T) {
+	message, err := parser.ParseChatMessage("whisper:3:meet at: B")
+	assert.Nil(t, err)
+	assert.Equal(t, CHAT_SCOPE_WHISPER, message.Scope)
+	assert.Equal(t, 3, message.TargetID)
+	assert.Equal(t, "meet at: B", message.Text)
+
+	_, err = parser.ParseChatMessage("all:hello")
+	assert.NotNil(t, err)
+
+	playerID, muted, err := parser.ParseMuteMessage("4:1")
+	assert.Nil(t, err)
+	assert.Equal(t, 4, playerID)
+	assert.True(t, muted)
+}
+
+func TestParseVoteMessage(t *testing.T) {
+	request, err := parser.ParseVoteMessage("kick:3")
+	assert.Nil(t, err)
+	assert.Equal(t, VOTE_TYPE_KICK, request.Type)
+	assert.Equal(t, "3", request.Arg)
+
+	request, err = parser.ParseVoteMessage("yes")
+	assert.Nil(t, err)
+	assert.Equal(t, "", request.Type)
+	assert.True(t, request.Ballot)
+
+	_, err = parser.ParseVoteMessage("")
+	assert.NotNil(t, err)
+}
+
+func TestParseLobbyMessage(t *testing.T) {
+	request, err := parser.ParseLobbyMessage("loadout:rocket")
+	assert.Nil(t, err)
+	assert.Equal(t, LobbyRequest{Action: LOBBY_ACTION_LOADOUT, Value: WEAPON_ROCKET}, request)
+
+	_, err = parser.ParseLobbyMessage("ready")
+	assert.NotNil(t, err)
+}
+
+func TestParseFireMessage(t *testing.T) {
+	fire, err := parser.ParseFireMessage("123")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(123), fire.LastUpdatedAt)
+	assert.Equal(t, WEAPON_DEFAULT, fire.Weapon)
+
+	fire, err = parser.ParseFireMessage("123:rocket:1.000,2.000,3.000:0.000,0.000,1.000")
+	assert.Nil(t, err)
+	assert.Equal(t, WEAPON_ROCKET, fire.Weapon)
+	assert.Equal(t, Position{x: 1, y: 2, z: 3}, fire.Origin)
+	assert.Equal(t, Position{z: 1}, fire.Direction)
+
+	_, err = parser.ParseFireMessage("123:rocket")
+	assert.NotNil(t, err)
+}
+
+func TestParseShotMessageZone(t *testing.T) {
+	shot, err := parser.ParseShotMessage("2:123")
+	assert.Nil(t, err)
+	assert.Equal(t, HIT_ZONE_BODY, shot.Zone)
+
+	shot, err = parser.ParseShotMessage("2:123:rifle:head")
+	assert.Nil(t, err)
+	assert.Equal(t, HIT_ZONE_HEAD, shot.Zone)
+
+	_, err = parser.ParseShotMessage("2:123:rifle:elbow")
+	assert.NotNil(t, err)
+}
+
+func TestParsePlayerState(t *testing.T) {
+	ps, err := parser.ParsePlayerState("1.000,2.000,3.000:90.000:123")
+	assert.Nil(t, err)
+	assert.Equal(t, Position{x: 1, y: 2, z: 3}, ps.Position)
+	assert.Equal(t, Orientation{Yaw: 90}, ps.Rotation)
+	assert.Equal(t, int64(123), ps.LastUpdatedAt)
+
+	ps, err = parser.ParsePlayerState("1.000,2.000,3.000:90.000,-10.000:123:4.000,0.000,0.000:45.000,5.000")
+	assert.Nil(t, err)
+	assert.Equal(t, Orientation{Yaw: 90, Pitch: -10}, ps.Rotation)
+	assert.Equal(t, Position{x: 4}, ps.Velocity)
+	assert.Equal(t, Orientation{Yaw: 45, Pitch: 5}, ps.AngularVelocity)
+	assert.Equal(t, 0, ps.Actions)
+
+	ps, err = parser.ParsePlayerState("1.000,2.000,3.000:90.000,-10.000:123:4.000,0.000,0.000:45.000,5.000:5")
+	assert.Nil(t, err)
+	assert.Equal(t, ACTION_FLAG_CROUCH|ACTION_FLAG_AIM, ps.Actions)
+
+	_, err = parser.ParsePlayerState("1.000,2.000,3.000:90.000")
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,242 @@
+package udp_server
+
+import (
+	"fmt"
+	"sync"
+)
+
+// PickupDef is a pickup placed in the map file
+type PickupDef struct {
+	Name       string   `json:"name"`
+	Type       string   `json:"type"` // one of the PICKUP_TYPE_* constants
+	Position   Position `json:"position"`
+	Amount     int      `json:"amount"`     // health or armor restored, ammo given
+	DurationMs int64    `json:"durationMs"` // how long a power up lasts
+	RespawnMs  int64    `json:"respawnMs"`
+	Radius     float64  `json:"radius"`
+}
+
+// Pickup is the component stored on pickup entities, ID is filled in from the entity when read
+type Pickup struct {
+	ID        int
+	Def       PickupDef
+	Active    bool
+	RespawnAt int64
+}
+
+func (p Pickup) ComponentType() string {
+	return COMPONENT_PICKUP
+}
+
+func (p Pickup) SnapshotString() string {
+	active := 0
+	if p.Active {
+		active = 1
+	}
+	return fmt.Sprintf("%s:%d", p.Def.Type, active)
+}
+
+func (p Pickup) String() string {
+	return fmt.Sprintf("%d:%s:%s", p.ID, p.Def.Type, p.Def.Position.String())
+}
+
+type PickupCollection struct {
+	PickupID    int
+	PlayerID    int
+	RespawnInMs int64
+}
+
+func (pc PickupCollection) String() string {
+	return fmt.Sprintf("%d:%d:%d", pc.PickupID, pc.PlayerID, pc.RespawnInMs)
+}
+
+type pickupClaim struct {
+	pickup Pickup
+	player PlayerState
+}
+
+// PickupManager keeps the map pickups as entities, mu makes a whole round of claims atomic
+type PickupManager struct {
+	mu        sync.Mutex
+	entities  *EntityRegistry
+	pickupIDs []int
+}
+
+func NewPickupManager(entities *EntityRegistry, defs []PickupDef) *PickupManager {
+	pkm := &PickupManager{entities: entities}
+	for _, def := range defs {
+		if def.Radius <= 0 {
+			def.Radius = PICKUP_DEFAULT_RADIUS
+		}
+		entity, err := entities.Create(ENTITY_TYPE_PICKUP, "", Transform{Position: def.Position}, Pickup{Def: def, Active: true})
+		if err != nil {
+			logger.warn("Unable to create pickup %s: %s", def.Name, err.Error())
+			continue
+		}
+		pkm.pickupIDs = append(pkm.pickupIDs, entity.ID)
+	}
+	return pkm
+}
+
+// Clear removes every pickup entity, used when switching maps
+func (pkm *PickupManager) Clear() {
+	pkm.mu.Lock()
+	defer pkm.mu.Unlock()
+	for _, id := range pkm.pickupIDs {
+		pkm.entities.Remove(id)
+	}
+	pkm.pickupIDs = nil
+}
+
+func (pkm *PickupManager) all() []Pickup {
+	pickups := []Pickup{}
+	for _, id := range pkm.pickupIDs {
+		entity, ok := pkm.entities.Get(id)
+		if !ok {
+			continue
+		}
+		pickup, ok := GetComponent[Pickup](entity)
+		if !ok {
+			continue
+		}
+		pickup.ID = entity.ID
+		pickups = append(pickups, pickup)
+	}
+	return pickups
+}
+
+func (pkm *PickupManager) update(pickup Pickup) {
+	pkm.entities.Modify(pickup.ID, func(e *Entity) {
+		e.SetComponent(pickup)
+	})
+}
+
+// ActivePickups returns every pickup that can currently be collected
+func (pkm *PickupManager) ActivePickups() []Pickup {
+	pkm.mu.Lock()
+	defer pkm.mu.Unlock()
+	active := []Pickup{}
+	for _, p := range pkm.all() {
+		if p.Active {
+			active = append(active, p)
+		}
+	}
+	return active
+}
+
+// respawnDue reactivates pickups whose timer ran out and returns them
+func (pkm *PickupManager) respawnDue(now int64) []Pickup {
+	pkm.mu.Lock()
+	defer pkm.mu.Unlock()
+	respawned := []Pickup{}
+	for _, p := range pkm.all() {
+		if !p.Active && now >= p.RespawnAt {
+			p.Active = true
+			pkm.update(p)
+			respawned = append(respawned, p)
+		}
+	}
+	return respawned
+}
+
+// claim picks a collector for every active pickup, the closest eligible player in range wins
+// and ties go to the lower player ID so two players arriving together get a single winner
+func (pkm *PickupManager) claim(players []PlayerState, canCollect func(ps PlayerState, def PickupDef) bool, now int64) []pickupClaim {
+	pkm.mu.Lock()
+	defer pkm.mu.Unlock()
+	claims := []pickupClaim{}
+	for _, p := range pkm.all() {
+		if !p.Active {
+			continue
+		}
+		bestDistance := -1.0
+		var winner PlayerState
+		for _, ps := range players {
+			distance := ps.Position.DistanceTo(p.Def.Position)
+			if distance > p.Def.Radius || !canCollect(ps, p.Def) {
+				continue
+			}
+			if bestDistance < 0 || distance < bestDistance || (distance == bestDistance && ps.ID < winner.ID) {
+				bestDistance = distance
+				winner = ps
+			}
+		}
+		if bestDistance < 0 {
+			continue
+		}
+		p.Active = false
+		p.RespawnAt = now + p.Def.RespawnMs
+		pkm.update(p)
+		claims = append(claims, pickupClaim{pickup: p, player: winner})
+	}
+	return claims
+}
+
+func (pm *PlayerManager) tickPickups(now int64) {
+	pickups := pm.currentLevel().pickups
+	for _, p := range pickups.respawnDue(now) {
+		pm.broadcast(parser.EncodePickupSpawns([]Pickup{p}))
+	}
+
+	canCollect := func(ps PlayerState, def PickupDef) bool {
+		return pm.canCollect(ps, def, now)
+	}
+	for _, claim := range pickups.claim(pm.GetAllPlayerStates(nil), canCollect, now) {
+		p, ps := claim.pickup, claim.player
+		pm.applyPickup(ps, p.Def, now)
+		logger.debug("Player %d collected pickup %d (%s)", ps.ID, p.ID, p.Def.Type)
+		pm.broadcastReliable(parser.EncodePickupCollection(PickupCollection{
+			PickupID:    p.ID,
+			PlayerID:    ps.ID,
+			RespawnInMs: p.Def.RespawnMs,
+		}))
+	}
+}
+
+func (pm *PlayerManager) canCollect(ps PlayerState, def PickupDef, now int64) bool {
+	// still waiting to respawn
+	if now-ps.RespawnAt < RESPAWN_IDLE_DELAY_MS || ps.Health <= 0 {
+		return false
+	}
+	switch def.Type {
+	case PICKUP_TYPE_HEALTH:
+		return ps.Health < MAX_HEALTH
+	case PICKUP_TYPE_ARMOR:
+		return ps.Armor < MAX_ARMOR
+	default:
+		return true
+	}
+}
+
+func (pm *PlayerManager) applyPickup(ps PlayerState, def PickupDef, now int64) {
+	pm.modifyPlayerState(ps.Addr.String(), func(ps *PlayerState) {
+		switch def.Type {
+		case PICKUP_TYPE_HEALTH:
+			ps.Health += def.Amount
+			if ps.Health > MAX_HEALTH {
+				ps.Health = MAX_HEALTH
+			}
+		case PICKUP_TYPE_ARMOR:
+			ps.Armor += def.Amount
+			if ps.Armor > MAX_ARMOR {
+				ps.Armor = MAX_ARMOR
+			}
+		case PICKUP_TYPE_DOUBLE_DAMAGE:
+			ps.Buff = BUFF_DOUBLE_DAMAGE
+			ps.BuffUntil = now + def.DurationMs
+		}
+		// ammo is tracked by the client, the collection message is enough
+	})
+}
+
+func validatePickupDef(def PickupDef) error {
+	switch def.Type {
+	case PICKUP_TYPE_HEALTH, PICKUP_TYPE_ARMOR, PICKUP_TYPE_AMMO, PICKUP_TYPE_DOUBLE_DAMAGE:
+	default:
+		return fmt.Errorf("pickup %s has unknown type %s", def.Name, def.Type)
+	}
+	if def.RespawnMs < 0 || def.Amount < 0 || def.DurationMs < 0 {
+		return fmt.Errorf("pickup %s has negative values", def.Name)
+	}
+	return nil
+}
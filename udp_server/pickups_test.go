@@ -0,0 +1,50 @@
+package udp_server
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPickupClaimGoesToClosestPlayer(t *testing.T) {
+	pkm := NewPickupManager(NewEntityRegistry(), []PickupDef{{Type: PICKUP_TYPE_AMMO, Position: Position{}, RespawnMs: 1000, Radius: 2}})
+	far := PlayerState{ID: 1, Position: Position{x: 1.5}}
+	near := PlayerState{ID: 2, Position: Position{x: 0.5}}
+	tied := PlayerState{ID: 3, Position: Position{x: -0.5}}
+	canCollect := func(ps PlayerState, def PickupDef) bool { return true }
+
+	claims := pkm.claim([]PlayerState{far, tied, near}, canCollect, 0)
+	assert.Len(t, claims, 1)
+	assert.Equal(t, near.ID, claims[0].player.ID)
+
+	// inactive until the respawn timer runs out
+	assert.Empty(t, pkm.claim([]PlayerState{near}, canCollect, 500))
+	assert.Empty(t, pkm.respawnDue(999))
+	assert.Len(t, pkm.respawnDue(1000), 1)
+	assert.Len(t, pkm.ActivePickups(), 1)
+}
+
+func TestHealthPickupCollection(t *testing.T) {
+	pm, packets := newMatchPlayerManager(t)
+	pm.SetMapData(MapData{
+		SpawnPoints: DefaultMapData().SpawnPoints,
+		Pickups:     []PickupDef{{Type: PICKUP_TYPE_HEALTH, Position: Position{x: 100}, Amount: 2, RespawnMs: 1000}},
+	})
+	shooter, _ := pm.CreatePlayer(newTestAddr(1), "shooter", NO_TEAM)
+	victim, _ := pm.CreatePlayer(newTestAddr(2), "victim", NO_TEAM)
+	shootPlayer(pm, shooter, victim)
+	shootPlayer(pm, shooter, victim)
+	shootPlayer(pm, shooter, victim)
+
+	now := time.Now().UnixMilli()
+	pm.UpdatePlayerState(shooter.Addr.String(), PlayerState{Position: Position{x: 100}, LastUpdatedAt: now})
+	pm.UpdatePlayerState(victim.Addr.String(), PlayerState{Position: Position{x: 100}, LastUpdatedAt: now})
+	pm.tickPickups(now)
+
+	// the shooter is at full health so only the victim can take it
+	victimState, _ := pm.GetPlayerState(victim.Addr.String())
+	assert.Equal(t, MAX_HEALTH-1, victimState.Health)
+	assert.True(t, hasReliablePacket(*packets, fmt.Sprintf("%s;1:%d:1000", PICKUP_COLLECT_MESSAGE, victim.ID)))
+}
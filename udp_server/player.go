@@ -2,22 +2,73 @@ package udp_server
 
 import (
 	"fmt"
-	"math/rand"
+	"math"
 	"net"
 	"time"
 )
 
 type PlayerState struct {
-	ID            int
-	Addr          *net.UDPAddr
-	Name          string
-	Position      Position
-	Health        int
-	Score         int
-	Deaths        int
-	Rotation      float32
-	RespawnAt     int64
+	ID        int
+	Addr      *net.UDPAddr
+	Name      string
+	Team      int
+	Position  Position
+	Health    int
+	Armor     int
+	Score     int
+	Deaths    int
+	Assists   int
+	Headshots int // kills with a headshot
+	Rotation  Orientation
+	Velocity  Position
+	// degrees per second around each axis of Rotation
+	AngularVelocity Orientation
+	Actions         int // ACTION_FLAG_* bits
+	RespawnAt       int64
+	// spawn protection ends at this time, or as soon as the player fires
+	ProtectedUntil int64
+	// regeneration starts once the player goes RegenDelayMs without damage
+	LastDamagedAt int64
+	LastRegenAt   int64
+	LastHazardAt  int64
+	// kills since the last death, and kills in quick succession
+	Streak        int
+	MultiKill     int
+	LastKillAt    int64
+	Buff          string
+	BuffUntil     int64
 	LastUpdatedAt int64
+	// server time of the last state update, LastUpdatedAt is on the clients clock
+	ReceivedAt int64
+	// movement is checked against this accepted position and the server time it arrived, see checkSpeed
+	SpeedCheckFrom Position
+	SpeedCheckAt   int64
+	// picked in the lobby, Ready is cleared when the match starts
+	Loadout string
+	Ready   bool
+	// the part of a zone damage below a whole point, added to the next hit so limb hits add up
+	DamageCarry float64
+}
+
+// Orientation is yaw and pitch in degrees
+type Orientation struct {
+	Yaw   float32
+	Pitch float32
+}
+
+func (o Orientation) String() string {
+	return fmt.Sprintf("%.3f,%.3f", o.Yaw, o.Pitch)
+}
+
+// Direction is the unit vector the orientation looks along, yaw 0 faces +z and turns towards +x like lookAt
+func (o Orientation) Direction() Position {
+	yaw := float64(o.Yaw) * math.Pi / 180
+	pitch := float64(o.Pitch) * math.Pi / 180
+	return Position{
+		x: float32(math.Sin(yaw) * math.Cos(pitch)),
+		y: float32(math.Sin(pitch)),
+		z: float32(math.Cos(yaw) * math.Cos(pitch)),
+	}
 }
 
 type Position struct {
@@ -26,21 +77,21 @@ type Position struct {
 	z float32
 }
 
-func RandomPosition() Position {
-	return Position{
-		x: rand.Float32() * 10,
-		y: 10,
-		z: rand.Float32() * 10,
-	}
+func (p Position) DistanceTo(other Position) float64 {
+	dx := float64(p.x - other.x)
+	dy := float64(p.y - other.y)
+	dz := float64(p.z - other.z)
+	return math.Sqrt(dx*dx + dy*dy + dz*dz)
 }
 
-func NewPlayer(id int, addr *net.UDPAddr, name string) PlayerState {
+func NewPlayer(id int, addr *net.UDPAddr, name string, team int) PlayerState {
 	return PlayerState{
 		ID:            id,
 		Addr:          addr,
 		Name:          name,
-		Position:      RandomPosition(),
-		Rotation:      0,
+		Team:          team,
+		Loadout:       WEAPON_DEFAULT,
+		Rotation:      Orientation{},
 		Health:        MAX_HEALTH,
 		Score:         0,
 		Deaths:        0,
@@ -49,13 +100,34 @@ func NewPlayer(id int, addr *net.UDPAddr, name string) PlayerState {
 	}
 }
 
+func (ps PlayerState) ComponentType() string {
+	return COMPONENT_PLAYER
+}
+
+func (ps *PlayerState) IsProtected(now int64) bool {
+	return now < ps.ProtectedUntil
+}
+
 func (ps *PlayerState) String() string {
-	return fmt.Sprintf("%d:%s:%.3f:%d:%d", ps.ID, ps.Position.String(), ps.Rotation, ps.Health, ps.LastUpdatedAt)
+	protected := 0
+	if ps.IsProtected(time.Now().UnixMilli()) {
+		protected = 1
+	}
+	return fmt.Sprintf("%d:%s:%s:%d:%d:%d:%d:%d:%s:%s:%d", ps.ID, ps.Position.String(), ps.Rotation.String(), ps.Health, ps.LastUpdatedAt, protected, ps.Team, ps.Armor, ps.Velocity.String(), ps.AngularVelocity.String(), ps.Actions)
 }
 func (ps Position) String() string {
 	return fmt.Sprintf("%.3f,%.3f,%.3f", ps.x, ps.y, ps.z)
 }
 
 func (ps *PlayerState) ScoreString() string {
-	return fmt.Sprintf("%d:%d:%d", ps.ID, ps.Score, ps.Deaths)
+	return fmt.Sprintf("%d:%d:%d:%d:%d:%d", ps.ID, ps.Team, ps.Score, ps.Deaths, ps.Assists, ps.Headshots)
+}
+
+// LobbyString is the players entry in the lobby roster
+func (ps *PlayerState) LobbyString() string {
+	ready := 0
+	if ps.Ready {
+		ready = 1
+	}
+	return fmt.Sprintf("%d:%s:%d:%s:%d", ps.ID, ps.Name, ps.Team, ps.Loadout, ready)
 }
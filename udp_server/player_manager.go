@@ -2,43 +2,222 @@ package udp_server
 
 import (
 	"fmt"
+	"math"
 	"net"
 	"sync"
 	"time"
 )
 
 type PlayerManager struct {
-	IDGenerator     int
-	players         sync.Map // Concurrent map for player states
-	playerIDMu      sync.RWMutex
-	playerIDAddrMap map[int]string
+	entities     *EntityRegistry // players and every other world entity
+	joinMu       sync.Mutex      // serializes logins so team balancing sees every player
+	levelMu      sync.RWMutex
+	level        *Level
+	config       Config
+	gameMode     GameMode
+	match        *Match
+	sender       func(addr *net.UDPAddr, packet string)
+	reliable     *ReliableSender
+	damageLedger *DamageLedger
+	history      *PositionHistory
+	chat         *ChatManager
+	votes        *VoteManager
+	joinQueue    *JoinQueue
+	botCount     int32 // bots ever added, numbers their names and addresses
+	teamScoresMu sync.Mutex
+	teamScores   map[int]int
 }
 
 func NewPlayerManager() *PlayerManager {
-	return &PlayerManager{
-		playerIDAddrMap: make(map[int]string),
+	pm := &PlayerManager{
+		config:     DefaultConfig(),
+		gameMode:   &FreeForAll{},
+		match:      NewMatch(),
+		sender:     func(addr *net.UDPAddr, packet string) {},
+		entities:   NewEntityRegistry(),
+		teamScores: make(map[int]int),
 	}
+	pm.level = NewLevel(DefaultMapData(), pm.entities)
+	pm.reliable = NewReliableSender(pm.send)
+	pm.damageLedger = NewDamageLedger()
+	pm.history = NewPositionHistory()
+	pm.chat = NewChatManager()
+	pm.votes = NewVoteManager()
+	pm.joinQueue = NewJoinQueue()
+	return pm
 }
 
-func (pm *PlayerManager) CreatePlayer(addr *net.UDPAddr, name string) (PlayerState, error) {
-	// check if player already logged in once
-	_, ok := pm.players.Load(addr.String())
-	if ok {
+func (pm *PlayerManager) SetConfig(config Config) error {
+	if err := config.Validate(); err != nil {
+		return err
+	}
+	pm.config = config
+	pm.gameMode = NewGameMode(config)
+	return nil
+}
+
+// SetPacketSender lets the manager push packets for events that dont come from a client message
+func (pm *PlayerManager) SetPacketSender(sender func(addr *net.UDPAddr, packet string)) {
+	pm.sender = sender
+}
+
+func (pm *PlayerManager) send(addr *net.UDPAddr, packet string) {
+	if isBotAddr(addr) {
+		return
+	}
+	pm.sender(addr, packet)
+}
+
+func (pm *PlayerManager) broadcast(packet string) {
+	for _, addr := range pm.GetRecipientAddrs() {
+		pm.send(addr, packet)
+	}
+}
+
+func (pm *PlayerManager) broadcastReliable(packet string) {
+	now := time.Now().UnixMilli()
+	for _, addr := range pm.GetRecipientAddrs() {
+		pm.sendReliable(addr, packet, now)
+	}
+}
+
+func (pm *PlayerManager) sendReliable(addr *net.UDPAddr, packet string, now int64) {
+	// bots never ack, dont keep resending to them
+	if isBotAddr(addr) {
+		return
+	}
+	pm.reliable.Send(addr, packet, now)
+}
+
+func (pm *PlayerManager) AckReliable(addr *net.UDPAddr, seq int) {
+	pm.reliable.Ack(addr, seq)
+}
+
+func (pm *PlayerManager) BroadcastScores() {
+	pm.broadcast(parser.EncodePlayerScores(pm.GetAllPlayerStates(nil)))
+	if teamScores := pm.GetTeamScores(); len(teamScores) > 0 {
+		pm.broadcast(parser.EncodeTeamScores(teamScores))
+	}
+}
+
+// SetMapData swaps in a new map, packet handlers see either the old or the new map but never a mix
+func (pm *PlayerManager) SetMapData(mapData MapData) {
+	level := NewLevel(mapData, pm.entities)
+	pm.levelMu.Lock()
+	old := pm.level
+	pm.level = level
+	pm.levelMu.Unlock()
+	old.pickups.Clear()
+}
+
+// currentLevel is the loaded map, hold on to the result instead of calling it again so one action uses one map
+func (pm *PlayerManager) currentLevel() *Level {
+	pm.levelMu.RLock()
+	defer pm.levelMu.RUnlock()
+	return pm.level
+}
+
+func (pm *PlayerManager) GetActivePickups() []Pickup {
+	return pm.currentLevel().pickups.ActivePickups()
+}
+
+// PickSpawnPosition picks a spawn away from every enemy of the given player
+func (pm *PlayerManager) PickSpawnPosition(player PlayerState) Position {
+	enemyPositions := []Position{}
+	for _, ps := range pm.GetAllPlayerStates(nil) {
+		if !player.IsEnemy(ps) {
+			continue
+		}
+		enemyPositions = append(enemyPositions, ps.Position)
+	}
+	return pm.currentLevel().spawnManager.PickSpawnPosition(enemyPositions)
+}
+
+func playerEntityKey(addrStr string) string {
+	return ENTITY_TYPE_PLAYER + ":" + addrStr
+}
+
+func (pm *PlayerManager) CreatePlayer(addr *net.UDPAddr, name string, requestedTeam int) (PlayerState, error) {
+	// hold the lock until the player is stored so concurrent logins see each other when picking teams
+	pm.joinMu.Lock()
+	defer pm.joinMu.Unlock()
+
+	// check if player already logged in once, as a player or a spectator
+	if pm.isLoggedIn(addr.String()) {
 		return PlayerState{}, fmt.Errorf("client %s: Cant login more than once", addr.String())
 	}
+	if pm.votes.IsBanned(addr, name) {
+		return PlayerState{}, &LoginError{Reason: LOGIN_REJECT_BANNED}
+	}
 
-	pm.playerIDMu.Lock()
+	name, err := pm.resolveName(name)
+	if err != nil {
+		return PlayerState{}, err
+	}
+	if err := pm.admit(addr, name, requestedTeam, time.Now().UnixMilli()); err != nil {
+		return PlayerState{}, err
+	}
+	return pm.addPlayer(addr, name, requestedTeam)
+}
 
-	pm.IDGenerator++
-	playerId := pm.IDGenerator
-	pm.playerIDAddrMap[playerId] = addr.String()
+// addPlayer stores a new player who got through the login checks, callers must hold joinMu
+func (pm *PlayerManager) addPlayer(addr *net.UDPAddr, name string, requestedTeam int) (PlayerState, error) {
+	playerState := NewPlayer(pm.entities.ReserveID(), addr, name, NO_TEAM)
+	pm.gameMode.OnJoin(pm, &playerState, requestedTeam)
+	playerState.Position = pm.PickSpawnPosition(playerState)
+	playerState.ProtectedUntil = playerState.LastUpdatedAt + pm.config.SpawnProtectionMs
+	playerState.ReceivedAt = time.Now().UnixMilli()
+	playerState.Armor = pm.config.SpawnArmor
 
-	pm.playerIDMu.Unlock()
+	if _, err := pm.entities.Add(playerState.ID, ENTITY_TYPE_PLAYER, playerEntityKey(addr.String()), playerState); err != nil {
+		return PlayerState{}, err
+	}
+	pm.history.Record(playerState.ID, playerState.Position, playerState.Rotation, 0, playerState.ReceivedAt, 0)
 
-	playerState := NewPlayer(playerId, addr, name)
+	return playerState, nil
+}
+
+// WelcomePlayer sends a new player the world as it is and tells everyone else they joined
+func (pm *PlayerManager) WelcomePlayer(newPlayerState PlayerState) {
+	// Send all logged in players
+	existingPlayerStates := pm.GetAllPlayerStates(newPlayerState.Addr)
+	initPacket := parser.EncodePlayerStatesForInit(
+		newPlayerState,
+		existingPlayerStates,
+		pm.GetSnapshotEntities(),
+		pm.GetChatHistory(newPlayerState.Team),
+	)
 
-	pm.players.Store(addr.String(), playerState)
+	// logger.log(LOG_LEVEL_DEBUG, "Player %d: Init packet (%s)", newPlayerState.ID, initPacket)
+	pm.send(newPlayerState.Addr, initPacket)
+	pm.send(newPlayerState.Addr, parser.EncodeMatchPhase(pm.match.Phase(), pm.match.RemainingMs(time.Now().UnixMilli())))
+	if pickups := pm.GetActivePickups(); len(pickups) > 0 {
+		pm.send(newPlayerState.Addr, parser.EncodePickupSpawns(pickups))
+	}
+
+	// broadcast to all players and spectators that new player is here
+	packet := parser.EncodePlayerStateForInit(newPlayerState)
+	for _, addr := range pm.GetRecipientAddrs() {
+		if addr.String() != newPlayerState.Addr.String() {
+			pm.send(addr, packet)
+		}
+	}
+	pm.RefreshLobby()
+}
+
+func (pm *PlayerManager) RemovePlayer(addrStr string) (PlayerState, error) {
+	playerState, err := pm.GetPlayerState(addrStr)
+	if err != nil {
+		return PlayerState{}, err
+	}
+	pm.entities.Remove(playerState.ID)
+	pm.reliable.Forget(playerState.Addr)
+	pm.damageLedger.Clear(playerState.ID)
+	pm.history.Forget(playerState.ID)
+	pm.chat.Forget(playerState.ID)
 
+	pm.gameMode.OnLeave(pm, playerState)
+	pm.retargetSpectators(playerState.ID)
 	return playerState, nil
 }
 
@@ -53,128 +232,401 @@ func (pm *PlayerManager) UpdatePlayerState(addrStr string, newPlayerState Player
 	if newPlayerState.LastUpdatedAt-oldState.RespawnAt < RESPAWN_IDLE_DELAY_MS {
 		return fmt.Errorf("player state updated before respawn delay")
 	}
+	receivedAt := time.Now().UnixMilli()
+	if !pm.checkBounds(oldState, newPlayerState.Position, receivedAt) {
+		return fmt.Errorf("player %d reported a position outside the world", oldState.ID)
+	}
+	newPlayerState.Actions = sanitizeActions(newPlayerState.Actions)
+	if !pm.checkSpeed(oldState, newPlayerState, receivedAt) {
+		return fmt.Errorf("player %d moved faster than allowed", oldState.ID)
+	}
 
-	newState := PlayerState{
-		ID:            oldState.ID,
-		Addr:          oldState.Addr,
-		Name:          oldState.Name,
-		Health:        oldState.Health,
-		Rotation:      newPlayerState.Rotation,
-		Position:      newPlayerState.Position,
-		Score:         oldState.Score,
-		Deaths:        oldState.Deaths,
-		RespawnAt:     oldState.RespawnAt,
-		LastUpdatedAt: newPlayerState.LastUpdatedAt,
+	updatedState, err := pm.modifyPlayerState(addrStr, func(ps *PlayerState) {
+		// the first update a check interval after the origin becomes the new origin
+		ps.SpeedCheckFrom, ps.SpeedCheckAt = speedCheckOrigin(*ps)
+		if receivedAt-ps.SpeedCheckAt >= SPEED_CHECK_MIN_MS {
+			ps.SpeedCheckFrom = newPlayerState.Position
+			ps.SpeedCheckAt = receivedAt
+		}
+		if newPlayerState.Rotation.IsFinite() {
+			ps.Rotation = newPlayerState.Rotation
+		}
+		ps.Position = newPlayerState.Position
+		ps.Velocity = newPlayerState.Velocity
+		ps.AngularVelocity = newPlayerState.AngularVelocity
+		if !ps.Velocity.IsFinite() {
+			ps.Velocity = Position{}
+		}
+		if !ps.AngularVelocity.IsFinite() {
+			ps.AngularVelocity = Orientation{}
+		}
+		ps.Actions = newPlayerState.Actions
+		ps.LastUpdatedAt = newPlayerState.LastUpdatedAt
+		ps.ReceivedAt = receivedAt
+	})
+	if err == nil {
+		pm.history.Record(oldState.ID, updatedState.Position, updatedState.Rotation, updatedState.Actions, receivedAt, newPlayerState.LastUpdatedAt)
 	}
-	// Update player's state in the concurrent map
-	pm.players.Store(addrStr, newState)
+	return err
+}
 
+func (pm *PlayerManager) HandlePlayerShot(shot Shot, shooterAddr *net.UDPAddr) *net.UDPAddr {
+	// firing a weapon gives up spawn protection
+	pm.EndSpawnProtection(shooterAddr.String())
+
+	weapon, ok := GetWeapon(shot.Weapon)
+	if !ok || weapon.Projectile {
+		logger.warn("Client %s: %s is not a hitscan weapon", shooterAddr.String(), shot.Weapon)
+		return nil
+	}
+	shooterState, err := pm.GetPlayerState(shooterAddr.String())
+	if err != nil {
+		logger.warn(err.Error())
+		return nil
+	}
+	if !pm.canUseWeapon(shooterState, weapon.Name) {
+		logger.warn("Player %d shot with %s outside their %s loadout", shooterState.ID, weapon.Name, shooterState.Loadout)
+		return nil
+	}
+	targetState, err := pm.GetPlayerStateByID(shot.HitPlayerID)
+	if err != nil {
+		logger.warn("Player %d shot unknown Player %d: %s", shooterState.ID, shot.HitPlayerID, err.Error())
+		return nil
+	}
+	zone, ok := pm.resolveHitZone(shot, shooterState, targetState)
+	if !ok {
+		logger.warn("Player %d shot Player %d through a wall, ignoring hit", shooterState.ID, shot.HitPlayerID)
+		return nil
+	}
+	if zone != shot.Zone {
+		logger.debug("Player %d claimed a %s hit on Player %d, counting it as %s", shooterState.ID, shot.Zone, shot.HitPlayerID, zone)
+		shot.Zone = zone
+	}
+	damage, err := pm.takeZoneDamage(targetState.Addr.String(), weapon.ZoneDamage(zone))
+	if err != nil {
+		logger.warn(err.Error())
+		return nil
+	}
+	if damage == 0 {
+		return nil
+	}
+	receieverState, died := pm.DamagePlayer(shot, shooterState, damage)
+	if died {
+		return receieverState.Addr
+	}
 	return nil
 }
 
-func (pm *PlayerManager) HandlePlayerShot(receiverID int, shooterAddr *net.UDPAddr, lastUpdatedAt int64) *net.UDPAddr {
-	pm.playerIDMu.RLock()
-	receieverAddr, ok := pm.playerIDAddrMap[receiverID]
+// resolveHitZone rewinds both players to when the shot was fired and casts the shooters aim at the target.
+// The zone the aim enters first is used, but never one above the claimed zone, and an aim that misses the
+// target can at best be a body hit. A hidden zone is downgraded to a visible lower one
+func (pm *PlayerManager) resolveHitZone(shot Shot, shooterState PlayerState, targetState PlayerState) (string, bool) {
+	now := time.Now().UnixMilli()
+	shotAt := pm.history.ServerTime(shooterState.ID, shot.LastUpdatedAt)
+	if shotAt > now {
+		shotAt = now
+	}
+	if shotAt < now-POSITION_HISTORY_MS {
+		shotAt = now - POSITION_HISTORY_MS
+	}
+	shooter, ok := pm.history.At(shooterState.ID, shotAt)
 	if !ok {
-		logger.warn("Player %d doesnt exist", receiverID)
-		return nil
+		shooter = PositionSample{Position: shooterState.Position, Rotation: shooterState.Rotation, Actions: shooterState.Actions}
+	}
+	target, ok := pm.history.At(targetState.ID, shotAt)
+	if !ok {
+		target = PositionSample{Position: targetState.Position, Actions: targetState.Actions}
+	}
+
+	eyes := shooter.Position.Add(Position{y: eyeHeight(shooter.Actions)})
+	aimed, ok := AimedZone(eyes, shooter.Rotation, target.Position, target.Actions)
+	if !ok {
+		aimed = HIT_ZONE_BODY
+	}
+	rank := zoneRank(shot.Zone)
+	if aimedRank := zoneRank(aimed); aimedRank > rank {
+		rank = aimedRank
+	}
+	geometry := pm.currentLevel().geometry
+	for _, zone := range hitZoneOrder[rank:] {
+		box, _ := ZoneBox(target.Position, zone, target.Actions)
+		if geometry.LineOfSight(eyes, box.Center()) {
+			return zone, true
+		}
 	}
+	return "", false
+}
+
+// takeZoneDamage adds a zone damage to the players carried fraction and returns the whole points to deal now
+func (pm *PlayerManager) takeZoneDamage(addrStr string, zoneDamage float64) (int, error) {
+	damage := 0
+	_, err := pm.modifyPlayerState(addrStr, func(ps *PlayerState) {
+		total := ps.DamageCarry + zoneDamage
+		damage = int(math.Floor(total))
+		ps.DamageCarry = total - float64(damage)
+	})
+	return damage, err
+}
 
-	pm.playerIDMu.RUnlock()
-	receieverState, err := pm.GetPlayerState(receieverAddr)
+// DamagePlayer runs damage from the attacker through the match rules and reports whether the victim died
+func (pm *PlayerManager) DamagePlayer(shot Shot, attackerState PlayerState, damage int) (PlayerState, bool) {
+	receiverID := shot.HitPlayerID
+	receieverState, err := pm.GetPlayerStateByID(receiverID)
 	if err != nil {
 		logger.warn("Unable to get Player %d state: %s", receiverID, err.Error())
+		return PlayerState{}, false
 	}
-	if receieverState.Health > 1 {
-		HandlePlayerHealthLoss(receieverState, lastUpdatedAt, pm, receieverAddr)
-	} else {
-		// Handle player respawn
-		HandlePlayerDeath(receiverID, receieverState, lastUpdatedAt, pm, receieverAddr, shooterAddr)
-		return receieverState.Addr
+	if !pm.match.AcceptsDamage() {
+		return receieverState, false
 	}
-	return nil
+	if receieverState.IsProtected(time.Now().UnixMilli()) {
+		logger.debug("Player %d is spawn protected, ignoring hit", receiverID)
+		return receieverState, false
+	}
+	damage = pm.gameMode.OnDamage(pm, receieverState, attackerState, damage)
+	if damage <= 0 {
+		return receieverState, false
+	}
+	if attackerState.HasBuff(BUFF_DOUBLE_DAMAGE, time.Now().UnixMilli()) {
+		damage *= 2
+	}
+	receieverState, err = HandlePlayerHealthLoss(receieverState, attackerState, damage, shot.LastUpdatedAt, pm)
+	if err != nil {
+		logger.warn(err.Error())
+		return PlayerState{}, false
+	}
+	if receieverState.Health <= 0 {
+		HandlePlayerDeath(receieverState, attackerState, shot, pm)
+		return receieverState, true
+	}
+	return receieverState, false
 }
 
-func HandlePlayerDeath(receiverID int, receieverState PlayerState, lastUpdatedAt int64, pm *PlayerManager, receieverAddr string, shooterAddr *net.UDPAddr) {
-	logger.info("Player %d died, respawning", receiverID)
-	newState := PlayerState{
-		ID:            receieverState.ID,
-		Addr:          receieverState.Addr,
-		Name:          receieverState.Name,
-		Health:        MAX_HEALTH,
-		Rotation:      0.0,
-		Score:         receieverState.Score,
-		Deaths:        receieverState.Deaths + 1,
-		Position:      RandomPosition(),
-		RespawnAt:     time.Now().UnixMilli(),
-		LastUpdatedAt: lastUpdatedAt,
+func (pm *PlayerManager) EndSpawnProtection(addrStr string) {
+	_, err := pm.modifyPlayerState(addrStr, func(ps *PlayerState) {
+		ps.ProtectedUntil = 0
+	})
+	if err != nil {
+		logger.warn(err.Error())
 	}
-	pm.players.Store(receieverAddr, newState)
+}
 
-	shooterState, err := pm.GetPlayerState(shooterAddr.String())
+func HandlePlayerDeath(receieverState PlayerState, shooterState PlayerState, shot Shot, pm *PlayerManager) {
+	logger.info("Player %d killed by Player %d", receieverState.ID, shooterState.ID)
+	killEvent := NewKillEvent(shooterState, receieverState, shot.Weapon)
+	if shot.Zone == HIT_ZONE_HEAD {
+		killEvent.Flags |= KILL_FLAG_HEADSHOT
+	}
+	pm.awardAssists(receieverState, shooterState)
+	pm.endStreak(receieverState, shooterState)
+
+	countsForStreak := killEvent.Flags&(KILL_FLAG_SUICIDE|KILL_FLAG_TEAM_KILL) == 0
+	if countsForStreak {
+		if updatedShooter, err := pm.recordKill(shooterState, killEvent.Flags&KILL_FLAG_HEADSHOT != 0); err == nil {
+			shooterState = updatedShooter
+		}
+		if shooterState.Streak >= STREAK_ANNOUNCE_MIN {
+			killEvent.Flags |= KILL_FLAG_STREAK
+		}
+	}
+
+	pm.gameMode.OnDeath(pm, receieverState, shooterState, shot.LastUpdatedAt)
+	pm.broadcastReliable(parser.EncodeKillEvent(killEvent))
+	if countsForStreak {
+		pm.handleKillStreak(shooterState)
+	}
+}
+
+func HandlePlayerHealthLoss(receieverState PlayerState, attackerState PlayerState, damage int, lastUpdatedAt int64, pm *PlayerManager) (PlayerState, error) {
+	now := time.Now().UnixMilli()
+	// records are kept as long as they can still earn an assist or credit for an environment kill
+	keepMs := pm.config.AssistWindowMs
+	if keepMs < ENVIRONMENT_CREDIT_WINDOW_MS {
+		keepMs = ENVIRONMENT_CREDIT_WINDOW_MS
+	}
+	pm.damageLedger.Record(receieverState.ID, attackerState.ID, damage, now, keepMs)
+	return pm.modifyPlayerState(receieverState.Addr.String(), func(ps *PlayerState) {
+		// armor soaks up damage before health
+		absorbed := damage
+		if ps.Armor < absorbed {
+			absorbed = ps.Armor
+		}
+		ps.Armor -= absorbed
+		ps.Health -= damage - absorbed
+		ps.LastDamagedAt = now
+		ps.LastUpdatedAt = lastUpdatedAt
+	})
+}
+
+// awardAssists credits enemies of the victim who did enough recent damage, then clears the victims ledger
+func (pm *PlayerManager) awardAssists(victim PlayerState, killer PlayerState) {
+	assisters := pm.damageLedger.Assisters(victim.ID, killer.ID, time.Now().UnixMilli(), pm.config.AssistMinDamage, pm.config.AssistWindowMs)
+	pm.damageLedger.Clear(victim.ID)
+	for _, assisterID := range assisters {
+		assister, err := pm.GetPlayerStateByID(assisterID)
+		if err != nil || !assister.IsEnemy(victim) {
+			continue
+		}
+		pm.modifyPlayerState(assister.Addr.String(), func(ps *PlayerState) {
+			ps.Assists++
+		})
+	}
+}
+
+func (pm *PlayerManager) tickRegen(now int64) {
+	if pm.config.RegenAmount == 0 {
+		return
+	}
+	for _, ps := range pm.GetAllPlayerStates(nil) {
+		if ps.Health >= MAX_HEALTH || now-ps.LastDamagedAt < pm.config.RegenDelayMs {
+			continue
+		}
+		pm.modifyPlayerState(ps.Addr.String(), func(ps *PlayerState) {
+			// recheck, the player may have been hit since the snapshot
+			if ps.Health <= 0 || ps.Health >= MAX_HEALTH || now-ps.LastDamagedAt < pm.config.RegenDelayMs {
+				return
+			}
+			if now-ps.LastRegenAt < pm.config.RegenIntervalMs {
+				return
+			}
+			ps.Health += pm.config.RegenAmount
+			if ps.Health > MAX_HEALTH {
+				ps.Health = MAX_HEALTH
+			}
+			ps.LastRegenAt = now
+		})
+	}
+}
+
+// NotifyRespawn sends a killed player their new state, everyone else sees it in the next broadcast
+func (pm *PlayerManager) NotifyRespawn(addr *net.UDPAddr) {
+	respawnedState, err := pm.GetPlayerState(addr.String())
+	if err != nil {
+		logger.warn(err.Error())
+		return
+	}
+	pm.send(addr, parser.EncodePlayerResetMessage(respawnedState))
+}
+
+// RespawnPlayer moves a dead player to a fresh spawn with full health, counting the death
+func (pm *PlayerManager) RespawnPlayer(addrStr string, lastUpdatedAt int64) {
+	playerState, err := pm.GetPlayerState(addrStr)
+	if err != nil {
+		logger.warn(err.Error())
+		return
+	}
+	spawnPosition := pm.PickSpawnPosition(playerState)
+	respawnAt := time.Now().UnixMilli()
+	_, err = pm.modifyPlayerState(addrStr, func(ps *PlayerState) {
+		ps.Health = MAX_HEALTH
+		ps.Armor = pm.config.SpawnArmor
+		ps.Rotation = Orientation{}
+		ps.Velocity = Position{}
+		ps.AngularVelocity = Orientation{}
+		ps.Actions = 0
+		ps.ReceivedAt = respawnAt
+		ps.SpeedCheckAt = 0
+		ps.DamageCarry = 0
+		ps.Deaths++
+		ps.Position = spawnPosition
+		ps.RespawnAt = respawnAt
+		ps.ProtectedUntil = respawnAt + RESPAWN_IDLE_DELAY_MS + pm.config.SpawnProtectionMs
+		ps.LastUpdatedAt = lastUpdatedAt
+	})
+	if err != nil {
+		logger.warn(err.Error())
+		return
+	}
+	// the respawn is a teleport, shots must not be checked against the old positions
+	pm.history.Clear(playerState.ID)
+	pm.history.Record(playerState.ID, spawnPosition, Orientation{}, 0, respawnAt, 0)
+}
+
+func (pm *PlayerManager) AddPlayerScore(addrStr string, delta int) {
+	_, err := pm.modifyPlayerState(addrStr, func(ps *PlayerState) {
+		ps.Score += delta
+	})
+	if err != nil {
+		logger.warn(err.Error())
+	}
+}
+
+func (pm *PlayerManager) Tick(now int64) {
+	pm.reliable.ResendPending(now)
+	pm.tickMatch(now)
+	pm.tickVotes(now)
+	pm.tickJoinQueue(now)
+	pm.tickRegen(now)
+	pm.tickHazards(now)
+	pm.tickBots(now)
+	pm.tickPickups(now)
+	pm.tickProjectiles(now)
+	pm.gameMode.OnTick(pm, now)
+}
+
+func (pm *PlayerManager) GetMatch() *Match {
+	return pm.match
+}
+
+// modifyPlayerState applies fn to the stored state, serialized against other modifications
+func (pm *PlayerManager) modifyPlayerState(addrStr string, fn func(ps *PlayerState)) (PlayerState, error) {
+	entity, ok := pm.entities.GetByKey(playerEntityKey(addrStr))
+	if !ok {
+		return PlayerState{}, fmt.Errorf("client %s: No player state exists on server", addrStr)
+	}
+	var playerState PlayerState
+	_, err := pm.entities.Modify(entity.ID, func(e *Entity) {
+		playerState, _ = GetComponent[PlayerState](*e)
+		fn(&playerState)
+		e.SetComponent(playerState)
+	})
 	if err != nil {
-		logger.warn("Unable to get Client %s state: %s", shooterAddr.String(), err.Error())
-	}
-	newShooterState := PlayerState{
-		ID:            shooterState.ID,
-		Addr:          shooterState.Addr,
-		Name:          shooterState.Name,
-		Health:        shooterState.Health,
-		Rotation:      shooterState.Rotation,
-		Score:         shooterState.Score + 1,
-		Deaths:        shooterState.Deaths,
-		Position:      shooterState.Position,
-		RespawnAt:     shooterState.RespawnAt,
-		LastUpdatedAt: shooterState.LastUpdatedAt,
-	}
-	pm.players.Store(shooterAddr.String(), newShooterState)
-}
-
-func HandlePlayerHealthLoss(receieverState PlayerState, lastUpdatedAt int64, pm *PlayerManager, receieverAddr string) {
-	newState := PlayerState{
-		ID:            receieverState.ID,
-		Addr:          receieverState.Addr,
-		Name:          receieverState.Name,
-		Health:        receieverState.Health - 1,
-		Rotation:      receieverState.Rotation,
-		Score:         receieverState.Score,
-		Deaths:        receieverState.Deaths,
-		Position:      receieverState.Position,
-		RespawnAt:     receieverState.RespawnAt,
-		LastUpdatedAt: lastUpdatedAt,
-	}
-	pm.players.Store(receieverAddr, newState)
+		return PlayerState{}, fmt.Errorf("client %s: %s", addrStr, err.Error())
+	}
+	return playerState, nil
+}
+
+func (pm *PlayerManager) GetPlayerStateByID(playerID int) (PlayerState, error) {
+	entity, ok := pm.entities.Get(playerID)
+	if !ok || entity.Type != ENTITY_TYPE_PLAYER {
+		return PlayerState{}, fmt.Errorf("player %d doesnt exist", playerID)
+	}
+	return playerStateFromEntity(entity)
 }
 
 func (pm *PlayerManager) GetPlayerState(addrStr string) (PlayerState, error) {
-	state, ok := pm.players.Load(addrStr)
+	entity, ok := pm.entities.GetByKey(playerEntityKey(addrStr))
 	if !ok {
 		return PlayerState{}, fmt.Errorf("client %s: No player state exists on server", addrStr)
 	}
-	playerState, ok := state.(PlayerState)
+	return playerStateFromEntity(entity)
+}
+
+func playerStateFromEntity(entity Entity) (PlayerState, error) {
+	playerState, ok := GetComponent[PlayerState](entity)
 	if !ok {
-		return PlayerState{}, fmt.Errorf("client %s: Unable to type assert player state from map", addrStr)
+		return PlayerState{}, fmt.Errorf("entity %d: Unable to get player state component", entity.ID)
 	}
 	return playerState, nil
 }
 
 func (pm *PlayerManager) GetAllPlayerStates(skipAddr *net.UDPAddr) []PlayerState {
 	states := []PlayerState{}
-	// Iterate over the player states in the concurrent map
-	pm.players.Range(func(addrStr, state interface{}) bool {
-		if skipAddr != nil && addrStr == skipAddr.String() {
-			return true
+	for _, entity := range pm.entities.Query(ENTITY_TYPE_PLAYER) {
+		playerState, err := playerStateFromEntity(entity)
+		if err != nil {
+			logger.warn(err.Error())
+			continue
 		}
-		// Convert state to PlayerState type
-		playerState, ok := state.(PlayerState)
-		if !ok {
-			// Handle type assertion error
-			logger.warn("Client %s: Unable to type assert player state from map", addrStr)
-			return true // Continue iteration
+		if skipAddr != nil && playerState.Addr.String() == skipAddr.String() {
+			continue
 		}
 		states = append(states, playerState)
-		return true // Continue iteration
-	})
-	// logger.log(LOG_LEVEL_DEBUG, "states read: %d", len(states))
+	}
 	return states
 }
+
+// GetSnapshotEntities returns the non player entities that go into S and I messages
+func (pm *PlayerManager) GetSnapshotEntities() []Entity {
+	return pm.entities.SnapshotEntities()
+}
@@ -0,0 +1,146 @@
+package udp_server
+
+import (
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestAddr(port int) *net.UDPAddr {
+	return &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: port}
+}
+
+func TestSpawnProtectionBlocksDamage(t *testing.T) {
+	pm := NewPlayerManager()
+	shooter, _ := pm.CreatePlayer(newTestAddr(1), "shooter", NO_TEAM)
+	victim, _ := pm.CreatePlayer(newTestAddr(2), "victim", NO_TEAM)
+
+	shootPlayer(pm, shooter, victim)
+
+	victimState, _ := pm.GetPlayerState(victim.Addr.String())
+	assert.Equal(t, MAX_HEALTH, victimState.Health)
+
+	// the shooter gave up protection by firing
+	shooterState, _ := pm.GetPlayerState(shooter.Addr.String())
+	assert.False(t, shooterState.IsProtected(shooterState.LastUpdatedAt))
+}
+
+func TestFiringEndsSpawnProtectionWithoutAHit(t *testing.T) {
+	room := NewRoom(DEFAULT_ROOM_ID, 1000, func(addr *net.UDPAddr, packet string) {})
+	defer room.stop()
+	pm := room.playerManager
+	rifleman, _ := pm.CreatePlayer(newTestAddr(1), "rifleman", NO_TEAM)
+	rocketeer, _ := pm.CreatePlayer(newTestAddr(2), "rocketeer", NO_TEAM)
+
+	// a miss only sends F, there is no H to end protection
+	room.processMessage(rifleman.Addr, message{messageType: PLAYER_FIRE_MESSAGE, data: "1000"})
+	room.processMessage(rocketeer.Addr, message{messageType: PLAYER_FIRE_MESSAGE, data: "1000:rocket:0,1.6,0:0,0,1"})
+
+	for _, player := range []PlayerState{rifleman, rocketeer} {
+		playerState, _ := pm.GetPlayerState(player.Addr.String())
+		assert.Equal(t, int64(0), playerState.ProtectedUntil)
+	}
+}
+
+func TestSpawnProtectionDisabled(t *testing.T) {
+	pm, _ := newMatchPlayerManager(t)
+	shooter, _ := pm.CreatePlayer(newTestAddr(1), "shooter", NO_TEAM)
+	victim, _ := pm.CreatePlayer(newTestAddr(2), "victim", NO_TEAM)
+
+	shootPlayer(pm, shooter, victim)
+
+	victimState, _ := pm.GetPlayerState(victim.Addr.String())
+	assert.Equal(t, MAX_HEALTH-1, victimState.Health)
+}
+
+func TestAutoTeamAssignmentBalancesTeams(t *testing.T) {
+	pm, _ := newMatchPlayerManager(t, withTeams)
+	p1, _ := pm.CreatePlayer(newTestAddr(1), "p1", NO_TEAM)
+	p2, _ := pm.CreatePlayer(newTestAddr(2), "p2", NO_TEAM)
+	p3, _ := pm.CreatePlayer(newTestAddr(3), "p3", 2)
+
+	assert.NotEqual(t, p1.Team, p2.Team)
+	assert.Equal(t, 2, p3.Team)
+}
+
+func TestFriendlyFire(t *testing.T) {
+	pm, _ := newMatchPlayerManager(t, withTeams)
+	shooter, _ := pm.CreatePlayer(newTestAddr(1), "shooter", 1)
+	mate, _ := pm.CreatePlayer(newTestAddr(2), "mate", 1)
+	enemy, _ := pm.CreatePlayer(newTestAddr(3), "enemy", 2)
+
+	shootPlayer(pm, shooter, mate)
+	mateState, _ := pm.GetPlayerState(mate.Addr.String())
+	assert.Equal(t, MAX_HEALTH, mateState.Health)
+
+	for i := 0; i < MAX_HEALTH; i++ {
+		shootPlayer(pm, shooter, enemy)
+	}
+	assert.Equal(t, []TeamScore{{Team: 1, Score: 1}, {Team: 2, Score: 0}}, pm.GetTeamScores())
+
+	pm, _ = newMatchPlayerManager(t, withTeams, func(config *Config) {
+		config.FriendlyFire = true
+	})
+	shooter, _ = pm.CreatePlayer(newTestAddr(1), "shooter", 1)
+	mate, _ = pm.CreatePlayer(newTestAddr(2), "mate", 1)
+	shootPlayer(pm, shooter, mate)
+	mateState, _ = pm.GetPlayerState(mate.Addr.String())
+	assert.Equal(t, MAX_HEALTH-1, mateState.Health)
+}
+
+func TestKillScoresAndRespawns(t *testing.T) {
+	pm, _ := newMatchPlayerManager(t)
+	shooter, _ := pm.CreatePlayer(newTestAddr(1), "shooter", NO_TEAM)
+	victim, _ := pm.CreatePlayer(newTestAddr(2), "victim", NO_TEAM)
+
+	var diedAddr *net.UDPAddr
+	for i := 0; i < MAX_HEALTH && diedAddr == nil; i++ {
+		diedAddr = shootPlayer(pm, shooter, victim)
+	}
+	assert.Equal(t, victim.Addr, diedAddr)
+
+	victimState, _ := pm.GetPlayerState(victim.Addr.String())
+	assert.Equal(t, MAX_HEALTH, victimState.Health)
+	assert.Equal(t, 1, victimState.Deaths)
+	shooterState, _ := pm.GetPlayerState(shooter.Addr.String())
+	assert.Equal(t, 1, shooterState.Score)
+}
+
+func TestRemovePlayer(t *testing.T) {
+	pm := NewPlayerManager()
+	player, _ := pm.CreatePlayer(newTestAddr(1), "player", NO_TEAM)
+
+	removed, err := pm.RemovePlayer(player.Addr.String())
+	assert.Nil(t, err)
+	assert.Equal(t, player.ID, removed.ID)
+
+	_, err = pm.GetPlayerStateByID(player.ID)
+	assert.NotNil(t, err)
+	assert.Empty(t, pm.GetAllPlayerStates(nil))
+}
+
+func shootPlayer(pm *PlayerManager, shooter PlayerState, victim PlayerState) *net.UDPAddr {
+	shot := Shot{HitPlayerID: victim.ID, LastUpdatedAt: victim.LastUpdatedAt, Weapon: WEAPON_DEFAULT, Zone: HIT_ZONE_BODY}
+	return pm.HandlePlayerShot(shot, shooter.Addr)
+}
+
+func TestKillEventBroadcast(t *testing.T) {
+	pm, packets := newMatchPlayerManager(t)
+	shooter, _ := pm.CreatePlayer(newTestAddr(1), "shooter", NO_TEAM)
+	victim, _ := pm.CreatePlayer(newTestAddr(2), "victim", NO_TEAM)
+
+	for i := 0; i < MAX_HEALTH; i++ {
+		shootPlayer(pm, shooter, victim)
+	}
+	killPacket := fmt.Sprintf("%s;%d:%d:%s:0", KILL_MESSAGE, shooter.ID, victim.ID, WEAPON_DEFAULT)
+	sentTo := 0
+	for _, packet := range *packets {
+		if packet == fmt.Sprintf("%s;1;%s", RELIABLE_MESSAGE, killPacket) {
+			sentTo++
+		}
+	}
+	// sequence numbers are per client so both get the first one
+	assert.Equal(t, 2, sentTo)
+}
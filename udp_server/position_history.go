@@ -0,0 +1,91 @@
+package udp_server
+
+import "sync"
+
+type PositionSample struct {
+	Position Position
+	Rotation Orientation // where the player was aiming
+	Actions  int         // ACTION_FLAG_* bits, crouching changes the hit volume
+	At       int64       // server time the position was received
+}
+
+// PositionHistory keeps recent positions of every player so shots can be checked
+// against where the target was when the shooter fired, not where it is now
+type PositionHistory struct {
+	mu           sync.Mutex
+	samples      map[int][]PositionSample // player ID to samples, oldest first
+	clockOffsets map[int]int64            // player ID to server time minus client time
+}
+
+func NewPositionHistory() *PositionHistory {
+	return &PositionHistory{
+		samples:      make(map[int][]PositionSample),
+		clockOffsets: make(map[int]int64),
+	}
+}
+
+// Record stores a position and aim received at server time at, clientAt is the clients timestamp for it or 0 if unknown
+func (ph *PositionHistory) Record(playerID int, pos Position, rotation Orientation, actions int, at int64, clientAt int64) {
+	ph.mu.Lock()
+	defer ph.mu.Unlock()
+	samples := append(ph.samples[playerID], PositionSample{Position: pos, Rotation: rotation, Actions: actions, At: at})
+	// drop what is too old to ever be rewound to, keeping one sample before the window to interpolate from
+	oldest := 0
+	for oldest < len(samples)-1 && at-samples[oldest+1].At > POSITION_HISTORY_MS {
+		oldest++
+	}
+	ph.samples[playerID] = samples[oldest:]
+	if clientAt != 0 {
+		ph.clockOffsets[playerID] = at - clientAt
+	}
+}
+
+// ServerTime converts a timestamp from the players clock to the servers clock
+func (ph *PositionHistory) ServerTime(playerID int, clientAt int64) int64 {
+	ph.mu.Lock()
+	defer ph.mu.Unlock()
+	return clientAt + ph.clockOffsets[playerID]
+}
+
+// At returns where the player was and aimed at server time at, interpolating between samples
+// and clamping to the oldest and newest sample outside of them
+func (ph *PositionHistory) At(playerID int, at int64) (PositionSample, bool) {
+	ph.mu.Lock()
+	defer ph.mu.Unlock()
+	samples := ph.samples[playerID]
+	if len(samples) == 0 {
+		return PositionSample{}, false
+	}
+	// the latest sample at or before at, several samples can share a millisecond
+	for i := len(samples) - 1; i >= 0; i-- {
+		before := samples[i]
+		if before.At > at {
+			continue
+		}
+		if i == len(samples)-1 {
+			return before, true
+		}
+		after := samples[i+1]
+		fraction := float32(at-before.At) / float32(after.At-before.At)
+		before.Position = before.Position.Add(after.Position.Sub(before.Position).Scale(fraction))
+		before.Rotation = before.Rotation.Lerp(after.Rotation, fraction)
+		before.At = at
+		return before, true
+	}
+	return samples[0], true
+}
+
+// Clear forgets a players positions, called when they teleport by respawning
+func (ph *PositionHistory) Clear(playerID int) {
+	ph.mu.Lock()
+	defer ph.mu.Unlock()
+	delete(ph.samples, playerID)
+}
+
+// Forget drops everything about a player who left
+func (ph *PositionHistory) Forget(playerID int) {
+	ph.mu.Lock()
+	defer ph.mu.Unlock()
+	delete(ph.samples, playerID)
+	delete(ph.clockOffsets, playerID)
+}
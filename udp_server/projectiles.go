@@ -0,0 +1,217 @@
+package udp_server
+
+import (
+	"fmt"
+	"math"
+)
+
+// Projectile is the component stored on projectile entities, the entity Transform holds its position
+type Projectile struct {
+	OwnerID         int
+	Weapon          string
+	Velocity        Position
+	ExpiresAt       int64
+	LastSimulatedAt int64
+}
+
+func (p Projectile) ComponentType() string {
+	return COMPONENT_PROJECTILE
+}
+
+func (p Projectile) SnapshotString() string {
+	return fmt.Sprintf("%s:%d:%s", p.Weapon, p.OwnerID, p.Velocity.String())
+}
+
+type ProjectileSpawn struct {
+	EntityID int
+	OwnerID  int
+	Weapon   string
+	Position Position
+	Velocity Position
+}
+
+func (ps ProjectileSpawn) String() string {
+	return fmt.Sprintf("%d:%d:%s:%s:%s", ps.EntityID, ps.OwnerID, ps.Weapon, ps.Position.String(), ps.Velocity.String())
+}
+
+type ProjectileImpact struct {
+	EntityID    int
+	Position    Position
+	HitPlayerID int // 0 when nobody was hit directly
+}
+
+func (pi ProjectileImpact) String() string {
+	return fmt.Sprintf("%d:%s:%d", pi.EntityID, pi.Position.String(), pi.HitPlayerID)
+}
+
+// FireProjectile spawns a projectile for the shooter, the client origin is only trusted
+// when it is close to the shooters eyes so projectiles cant be fired from across the map
+func (pm *PlayerManager) FireProjectile(shooterAddr string, fire Fire, now int64) (Entity, error) {
+	weapon, ok := GetWeapon(fire.Weapon)
+	if !ok || !weapon.Projectile {
+		return Entity{}, fmt.Errorf("client %s: %s is not a projectile weapon", shooterAddr, fire.Weapon)
+	}
+	shooterState, err := pm.GetPlayerState(shooterAddr)
+	if err != nil {
+		return Entity{}, err
+	}
+	if !pm.canUseWeapon(shooterState, weapon.Name) {
+		return Entity{}, fmt.Errorf("client %s: %s is outside the %s loadout", shooterAddr, weapon.Name, shooterState.Loadout)
+	}
+	if !pm.match.AcceptsDamage() {
+		return Entity{}, fmt.Errorf("client %s: match is not accepting shots", shooterAddr)
+	}
+	direction := fire.Direction.Normalized()
+	if !fire.Origin.IsFinite() || direction.Length() == 0 || !direction.IsFinite() {
+		return Entity{}, fmt.Errorf("client %s: invalid projectile origin or direction", shooterAddr)
+	}
+
+	eyes := shooterState.Position.Add(Position{y: eyeHeight(shooterState.Actions)})
+	origin := fire.Origin
+	if origin.DistanceTo(eyes) > PROJECTILE_MAX_ORIGIN_OFFSET {
+		logger.debug("Player %d fired from too far away, moving projectile to their eyes", shooterState.ID)
+		origin = eyes
+	}
+
+	projectile := Projectile{
+		OwnerID:         shooterState.ID,
+		Weapon:          weapon.Name,
+		Velocity:        direction.Scale(weapon.Speed),
+		ExpiresAt:       now + weapon.LifetimeMs,
+		LastSimulatedAt: now,
+	}
+	entity, err := pm.entities.Create(ENTITY_TYPE_PROJECTILE, "", Transform{Position: origin}, projectile)
+	if err != nil {
+		return Entity{}, err
+	}
+	pm.broadcastReliable(parser.EncodeProjectileSpawn(ProjectileSpawn{
+		EntityID: entity.ID,
+		OwnerID:  projectile.OwnerID,
+		Weapon:   projectile.Weapon,
+		Position: origin,
+		Velocity: projectile.Velocity,
+	}))
+	return entity, nil
+}
+
+// tickProjectiles moves every projectile along its path and explodes the ones
+// that hit a player or a wall on the way or ran out of lifetime
+func (pm *PlayerManager) tickProjectiles(now int64) {
+	for _, entity := range pm.entities.Query(ENTITY_TYPE_PROJECTILE) {
+		projectile, ok := GetComponent[Projectile](entity)
+		if !ok {
+			continue
+		}
+		transform, _ := GetComponent[Transform](entity)
+		weapon, _ := GetWeapon(projectile.Weapon)
+
+		dt := float32(now-projectile.LastSimulatedAt) / 1000
+		if dt < 0 {
+			dt = 0
+		}
+		projectile.Velocity = projectile.Velocity.Sub(Position{y: weapon.Gravity * dt})
+		from := transform.Position
+		to := from.Add(projectile.Velocity.Scale(dt))
+
+		directHit, fraction, hit := pm.projectileHit(projectile, from, to)
+		// a wall in front of the player shields them
+		if wallFraction, hitWall := pm.currentLevel().geometry.RayCast(from, to); hitWall && (!hit || wallFraction < fraction) {
+			directHit, fraction, hit = nil, wallFraction, true
+		}
+		if hit {
+			impact := from.Add(to.Sub(from).Scale(float32(fraction)))
+			pm.explodeProjectile(entity.ID, projectile, weapon, impact, directHit, now)
+			continue
+		}
+		if now >= projectile.ExpiresAt {
+			pm.explodeProjectile(entity.ID, projectile, weapon, to, nil, now)
+			continue
+		}
+
+		projectile.LastSimulatedAt = now
+		transform.Position = to
+		pm.entities.Modify(entity.ID, func(e *Entity) {
+			e.SetComponent(projectile)
+			e.SetComponent(transform)
+		})
+	}
+}
+
+// projectileHit finds the first player other than the owner whose hit volume the segment passes through
+func (pm *PlayerManager) projectileHit(projectile Projectile, from Position, to Position) (*PlayerState, float64, bool) {
+	var directHit *PlayerState
+	closest := math.Inf(1)
+	for _, ps := range pm.GetAllPlayerStates(nil) {
+		if ps.ID == projectile.OwnerID || ps.Health <= 0 {
+			continue
+		}
+		fraction, ok := ps.HitBox().IntersectSegment(from, to)
+		if ok && fraction < closest {
+			closest = fraction
+			hitState := ps
+			directHit = &hitState
+		}
+	}
+	if directHit == nil {
+		return nil, 0, false
+	}
+	return directHit, closest, true
+}
+
+// explodeProjectile applies direct and splash damage and removes the projectile, splash falls off
+// linearly from the full amount at the impact to nothing at the edge of the radius
+func (pm *PlayerManager) explodeProjectile(entityID int, projectile Projectile, weapon WeaponDef, impact Position, directHit *PlayerState, now int64) {
+	pm.entities.Remove(entityID)
+
+	hitPlayerID := 0
+	if directHit != nil {
+		hitPlayerID = directHit.ID
+	}
+	pm.broadcastReliable(parser.EncodeProjectileImpact(ProjectileImpact{
+		EntityID:    entityID,
+		Position:    impact,
+		HitPlayerID: hitPlayerID,
+	}))
+
+	ownerState, err := pm.GetPlayerStateByID(projectile.OwnerID)
+	if err != nil {
+		// the owner left, nobody can be credited with the damage
+		logger.debug("Projectile %d owner is gone: %s", entityID, err.Error())
+		return
+	}
+
+	killed := false
+	if directHit != nil {
+		shot := Shot{HitPlayerID: directHit.ID, LastUpdatedAt: now, Weapon: projectile.Weapon}
+		if _, died := pm.DamagePlayer(shot, ownerState, weapon.Damage); died {
+			pm.NotifyRespawn(directHit.Addr)
+			killed = true
+		}
+	}
+	if weapon.SplashRadius > 0 {
+		geometry := pm.currentLevel().geometry
+		splashFrom := impact.Sub(projectile.Velocity.Normalized().Scale(PROJECTILE_SPLASH_OFFSET))
+		for _, ps := range pm.GetAllPlayerStates(nil) {
+			if ps.ID == hitPlayerID || ps.Health <= 0 {
+				continue
+			}
+			distance := ps.Center().DistanceTo(impact)
+			if distance >= weapon.SplashRadius || !geometry.LineOfSight(splashFrom, ps.Center()) {
+				continue
+			}
+			damage := int(math.Round(float64(weapon.SplashDamage) * (1 - distance/weapon.SplashRadius)))
+			if damage <= 0 {
+				continue
+			}
+			// the owner is not skipped, standing next to your own rocket hurts
+			shot := Shot{HitPlayerID: ps.ID, LastUpdatedAt: now, Weapon: projectile.Weapon}
+			if _, died := pm.DamagePlayer(shot, ownerState, damage); died {
+				pm.NotifyRespawn(ps.Addr)
+				killed = true
+			}
+		}
+	}
+	if killed {
+		pm.BroadcastScores()
+	}
+}
@@ -0,0 +1,97 @@
+package udp_server
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func placePlayer(pm *PlayerManager, ps PlayerState, pos Position) {
+	pm.UpdatePlayerState(ps.Addr.String(), PlayerState{Position: pos, LastUpdatedAt: time.Now().UnixMilli()})
+}
+
+func TestRocketDirectHit(t *testing.T) {
+	pm, packets := newMatchPlayerManager(t)
+	shooter, _ := pm.CreatePlayer(newTestAddr(1), "shooter", NO_TEAM)
+	victim, _ := pm.CreatePlayer(newTestAddr(2), "victim", NO_TEAM)
+	placePlayer(pm, shooter, Position{})
+	placePlayer(pm, victim, Position{z: 10})
+
+	fire := Fire{Weapon: WEAPON_ROCKET, Origin: Position{y: 1}, Direction: Position{z: 1}}
+	projectile, err := pm.FireProjectile(shooter.Addr.String(), fire, 0)
+	assert.Nil(t, err)
+	spawn := fmt.Sprintf("%s;%d:%d:%s:0.000,1.000,0.000:0.000,0.000,25.000", PROJECTILE_SPAWN_MESSAGE, projectile.ID, shooter.ID, WEAPON_ROCKET)
+	assert.True(t, hasReliablePacket(*packets, spawn))
+
+	// 25 units per second, still in flight after 200ms
+	pm.tickProjectiles(200)
+	_, ok := pm.entities.Get(projectile.ID)
+	assert.True(t, ok)
+
+	pm.tickProjectiles(500)
+	_, ok = pm.entities.Get(projectile.ID)
+	assert.False(t, ok)
+	victimState, _ := pm.GetPlayerState(victim.Addr.String())
+	assert.Equal(t, MAX_HEALTH-3, victimState.Health)
+}
+
+func TestRocketSplashFallsOff(t *testing.T) {
+	pm, _ := newMatchPlayerManager(t)
+	shooter, _ := pm.CreatePlayer(newTestAddr(1), "shooter", NO_TEAM)
+	near, _ := pm.CreatePlayer(newTestAddr(2), "near", NO_TEAM)
+	far, _ := pm.CreatePlayer(newTestAddr(3), "far", NO_TEAM)
+	placePlayer(pm, shooter, Position{})
+	placePlayer(pm, near, Position{x: 1, z: 10})
+	placePlayer(pm, far, Position{x: 20, z: 10})
+
+	// fired at the ground between the players, nobody is hit directly
+	fire := Fire{Weapon: WEAPON_ROCKET, Origin: Position{y: 1}, Direction: Position{z: 1}}
+	projectile, err := pm.FireProjectile(shooter.Addr.String(), fire, 0)
+	assert.Nil(t, err)
+	pm.entities.Modify(projectile.ID, func(e *Entity) {
+		e.SetComponent(Transform{Position: Position{y: 0.9, z: 10}})
+		e.SetComponent(Projectile{OwnerID: shooter.ID, Weapon: WEAPON_ROCKET})
+	})
+	pm.tickProjectiles(0)
+
+	nearState, _ := pm.GetPlayerState(near.Addr.String())
+	farState, _ := pm.GetPlayerState(far.Addr.String())
+	shooterState, _ := pm.GetPlayerState(shooter.Addr.String())
+	assert.Equal(t, MAX_HEALTH-1, nearState.Health)
+	assert.Equal(t, MAX_HEALTH, farState.Health)
+	assert.Equal(t, MAX_HEALTH, shooterState.Health)
+}
+
+func TestProjectileExpires(t *testing.T) {
+	pm, packets := newMatchPlayerManager(t)
+	shooter, _ := pm.CreatePlayer(newTestAddr(1), "shooter", NO_TEAM)
+	placePlayer(pm, shooter, Position{})
+
+	fire := Fire{Weapon: WEAPON_GRENADE, Origin: Position{x: 50, y: 1}, Direction: Position{y: 1}}
+	projectile, err := pm.FireProjectile(shooter.Addr.String(), fire, 0)
+	assert.Nil(t, err)
+	// the origin was too far from the shooter and got moved to their eyes
+	transform, _ := GetComponent[Transform](projectile)
+	assert.Equal(t, Position{y: PLAYER_EYE_HEIGHT}, transform.Position)
+
+	pm.tickProjectiles(2499)
+	_, ok := pm.entities.Get(projectile.ID)
+	assert.True(t, ok)
+	pm.tickProjectiles(2500)
+	_, ok = pm.entities.Get(projectile.ID)
+	assert.False(t, ok)
+	// a miss reports 0 as the hit player
+	impact := fmt.Sprintf("%s;%d:", PROJECTILE_IMPACT_MESSAGE, projectile.ID)
+	assert.True(t, strings.Contains(strings.Join(*packets, "\n"), impact))
+	assert.True(t, strings.HasSuffix((*packets)[len(*packets)-1], ":0"))
+}
+
+func TestFireRejectsHitscanWeapon(t *testing.T) {
+	pm, _ := newMatchPlayerManager(t)
+	shooter, _ := pm.CreatePlayer(newTestAddr(1), "shooter", NO_TEAM)
+	_, err := pm.FireProjectile(shooter.Addr.String(), Fire{Weapon: WEAPON_RIFLE, Direction: Position{z: 1}}, 0)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,47 @@
+package udp_server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHealthRegen(t *testing.T) {
+	pm, _ := newMatchPlayerManager(t)
+	shooter, _ := pm.CreatePlayer(newTestAddr(1), "shooter", NO_TEAM)
+	victim, _ := pm.CreatePlayer(newTestAddr(2), "victim", NO_TEAM)
+	shootPlayer(pm, shooter, victim)
+	shootPlayer(pm, shooter, victim)
+
+	hitAt := time.Now().UnixMilli()
+	pm.tickRegen(hitAt + pm.config.RegenDelayMs - 100)
+	victimState, _ := pm.GetPlayerState(victim.Addr.String())
+	assert.Equal(t, MAX_HEALTH-2, victimState.Health)
+
+	regenAt := hitAt + pm.config.RegenDelayMs + 100
+	pm.tickRegen(regenAt)
+	pm.tickRegen(regenAt + 1) // too soon for the next step
+	victimState, _ = pm.GetPlayerState(victim.Addr.String())
+	assert.Equal(t, MAX_HEALTH-1, victimState.Health)
+
+	pm.tickRegen(regenAt + pm.config.RegenIntervalMs)
+	pm.tickRegen(regenAt + 2*pm.config.RegenIntervalMs)
+	victimState, _ = pm.GetPlayerState(victim.Addr.String())
+	assert.Equal(t, MAX_HEALTH, victimState.Health)
+}
+
+func TestArmorAbsorbsDamageFirst(t *testing.T) {
+	pm, _ := newMatchPlayerManager(t, func(config *Config) {
+		config.SpawnArmor = 2
+	})
+	shooter, _ := pm.CreatePlayer(newTestAddr(1), "shooter", NO_TEAM)
+	victim, _ := pm.CreatePlayer(newTestAddr(2), "victim", NO_TEAM)
+
+	for i := 0; i < 3; i++ {
+		shootPlayer(pm, shooter, victim)
+	}
+	victimState, _ := pm.GetPlayerState(victim.Addr.String())
+	assert.Equal(t, 0, victimState.Armor)
+	assert.Equal(t, MAX_HEALTH-1, victimState.Health)
+}
@@ -0,0 +1,86 @@
+package udp_server
+
+import (
+	"fmt"
+	"net"
+	"sync"
+)
+
+type pendingPacket struct {
+	addr       *net.UDPAddr
+	packet     string
+	lastSentAt int64
+	attempts   int
+}
+
+// ReliableSender wraps packets with a per client sequence number and resends them until acked.
+// Clients must ack every Y packet and drop sequence numbers they have already seen.
+type ReliableSender struct {
+	mu      sync.Mutex
+	nextSeq map[string]int
+	pending map[string]map[int]*pendingPacket
+	send    func(addr *net.UDPAddr, packet string)
+}
+
+func NewReliableSender(send func(addr *net.UDPAddr, packet string)) *ReliableSender {
+	return &ReliableSender{
+		nextSeq: make(map[string]int),
+		pending: make(map[string]map[int]*pendingPacket),
+		send:    send,
+	}
+}
+
+func (rs *ReliableSender) Send(addr *net.UDPAddr, packet string, now int64) {
+	rs.mu.Lock()
+	addrStr := addr.String()
+	rs.nextSeq[addrStr]++
+	seq := rs.nextSeq[addrStr]
+	wrapped := fmt.Sprintf("%s;%d;%s", RELIABLE_MESSAGE, seq, packet)
+	if rs.pending[addrStr] == nil {
+		rs.pending[addrStr] = make(map[int]*pendingPacket)
+	}
+	rs.pending[addrStr][seq] = &pendingPacket{addr: addr, packet: wrapped, lastSentAt: now, attempts: 1}
+	rs.mu.Unlock()
+
+	rs.send(addr, wrapped)
+}
+
+func (rs *ReliableSender) Ack(addr *net.UDPAddr, seq int) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	delete(rs.pending[addr.String()], seq)
+}
+
+// Forget drops everything queued for a client that left
+func (rs *ReliableSender) Forget(addr *net.UDPAddr) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	delete(rs.pending, addr.String())
+	delete(rs.nextSeq, addr.String())
+}
+
+// ResendPending resends unacked packets older than RELIABLE_RESEND_MS, giving up after RELIABLE_MAX_ATTEMPTS
+func (rs *ReliableSender) ResendPending(now int64) {
+	resend := []*pendingPacket{}
+	rs.mu.Lock()
+	for addrStr, packets := range rs.pending {
+		for seq, pp := range packets {
+			if now-pp.lastSentAt < RELIABLE_RESEND_MS {
+				continue
+			}
+			if pp.attempts >= RELIABLE_MAX_ATTEMPTS {
+				logger.warn("Client %s: dropping reliable packet %d after %d attempts", addrStr, seq, pp.attempts)
+				delete(packets, seq)
+				continue
+			}
+			pp.attempts++
+			pp.lastSentAt = now
+			resend = append(resend, pp)
+		}
+	}
+	rs.mu.Unlock()
+
+	for _, pp := range resend {
+		rs.send(pp.addr, pp.packet)
+	}
+}
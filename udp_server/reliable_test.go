@@ -0,0 +1,40 @@
+package udp_server
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReliableSenderResendsUntilAcked(t *testing.T) {
+	sent := []string{}
+	rs := NewReliableSender(func(addr *net.UDPAddr, packet string) {
+		sent = append(sent, packet)
+	})
+	addr := newTestAddr(1)
+
+	rs.Send(addr, "K;1:2:rifle:0", 0)
+	assert.Equal(t, []string{"Y;1;K;1:2:rifle:0"}, sent)
+
+	rs.ResendPending(RELIABLE_RESEND_MS - 1)
+	assert.Len(t, sent, 1)
+	rs.ResendPending(RELIABLE_RESEND_MS)
+	assert.Len(t, sent, 2)
+
+	rs.Ack(addr, 1)
+	rs.ResendPending(10 * RELIABLE_RESEND_MS)
+	assert.Len(t, sent, 2)
+}
+
+func TestReliableSenderGivesUp(t *testing.T) {
+	sent := 0
+	rs := NewReliableSender(func(addr *net.UDPAddr, packet string) {
+		sent++
+	})
+	rs.Send(newTestAddr(1), "K;1:2:rifle:0", 0)
+	for i := int64(1); i <= 2*RELIABLE_MAX_ATTEMPTS; i++ {
+		rs.ResendPending(i * RELIABLE_RESEND_MS)
+	}
+	assert.Equal(t, RELIABLE_MAX_ATTEMPTS, sent)
+}
@@ -0,0 +1,337 @@
+package udp_server
+
+import (
+	"errors"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Room is one match with its own players, rules and ticks, every room shares the servers socket
+type Room struct {
+	ID              string
+	playerManager   *PlayerManager
+	send            func(addr *net.UDPAddr, packet string)
+	quitBroadcast   chan struct{}
+	quitGameTick    chan struct{}
+	broadcastTicker *time.Ticker
+	gameTicker      *time.Ticker
+	broadcastLock   sync.Mutex
+}
+
+func NewRoom(id string, broadcastDelayMs int, send func(addr *net.UDPAddr, packet string)) *Room {
+	r := &Room{
+		ID:              id,
+		playerManager:   NewPlayerManager(),
+		send:            send,
+		broadcastTicker: time.NewTicker(time.Duration(broadcastDelayMs) * time.Millisecond),
+		gameTicker:      time.NewTicker(GAME_TICK_MS * time.Millisecond),
+		quitBroadcast:   make(chan struct{}),
+		quitGameTick:    make(chan struct{}),
+	}
+	r.playerManager.SetPacketSender(send)
+	return r
+}
+
+func (r *Room) SetConfig(config Config) error {
+	return r.playerManager.SetConfig(config)
+}
+
+func (r *Room) LoadMap(path string) error {
+	mapData, err := LoadMapData(path)
+	if err != nil {
+		return err
+	}
+	r.playerManager.SetMapData(mapData)
+	logger.info("Room %s loaded map %s with %d spawn points, %d pickups and %d solids", r.ID, mapData.Name, len(mapData.SpawnPoints), len(mapData.Pickups), len(mapData.Geometry))
+	return nil
+}
+
+func (r *Room) start() {
+	go r.broadcastPlayerStates()
+	go r.runGameTicks()
+}
+
+func (r *Room) stop() {
+	close(r.quitBroadcast)
+	close(r.quitGameTick)
+	r.broadcastTicker.Stop()
+	r.gameTicker.Stop()
+}
+
+// processMessage handles every message from a client in this room except logins, which the server routes here first
+func (r *Room) processMessage(addr *net.UDPAddr, msg message) {
+	switch msg.messageType {
+	case PLAYER_SHOT_MESSAGE:
+		r.handlePlayerShotMessage(addr, msg.data)
+	case PLAYER_STATE_MESSAGE:
+		r.handlePlayerStateUpdate(addr, msg.data)
+	case PLAYER_FIRE_MESSAGE:
+		r.handlePlayerFire(addr, msg.data)
+	case PLAYER_LEAVE_MESSAGE:
+		r.handlePlayerLeave(addr)
+	case RELIABLE_MESSAGE:
+		r.handleReliableAck(addr, msg.data)
+	case SPECTATOR_CAMERA_MESSAGE:
+		r.handleSpectatorCamera(addr, msg.data)
+	case CHAT_MESSAGE:
+		r.handleChat(addr, msg.data)
+	case CHAT_MUTE_MESSAGE:
+		r.handleChatMute(addr, msg.data)
+	case VOTE_MESSAGE:
+		r.handleVote(addr, msg.data)
+	case LOBBY_MESSAGE:
+		r.handleLobby(addr, msg.data)
+	default:
+		logger.warn("Unknown message type: %s", msg.messageType)
+	}
+}
+
+func (r *Room) sendPacket(addr *net.UDPAddr, packet string) {
+	r.send(addr, packet)
+}
+
+func (r *Room) broadcastPacket(addrs []*net.UDPAddr, packet string) {
+	for _, addr := range addrs {
+		r.send(addr, packet)
+	}
+}
+
+func (r *Room) broadcastPlayerStates() {
+	for {
+		select {
+		case <-r.quitBroadcast:
+			return
+		case <-r.broadcastTicker.C:
+			// logger.log(LOG_LEVEL_DEBUG, "BROADCAST TICK")
+			if playerStates := r.playerManager.GetBroadcastStates(time.Now().UnixMilli()); len(playerStates) > 1 {
+				// go r.calculateBroadcastDelay(playerStates)
+
+				broadcastPacket := parser.EncodePlayerStatesForBroadcast(playerStates, r.playerManager.GetSnapshotEntities())
+				r.broadcastPacket(r.playerManager.GetRecipientAddrs(), broadcastPacket)
+			}
+		}
+	}
+}
+
+func (r *Room) runGameTicks() {
+	for {
+		select {
+		case <-r.quitGameTick:
+			return
+		case tick := <-r.gameTicker.C:
+			r.playerManager.Tick(tick.UnixMilli())
+		}
+	}
+}
+
+func (r *Room) handlePlayerStateUpdate(addr *net.UDPAddr, data string) {
+	ps, err := parser.ParsePlayerState(data)
+	if err != nil {
+		logger.warn("Unable to parse player state from packet (%s): %s", data, err)
+		return
+	}
+	addrStr := addr.String()
+	err = r.playerManager.UpdatePlayerState(addrStr, ps)
+	if err != nil {
+		logger.warn(err.Error())
+	}
+}
+
+func (r *Room) handlePlayerShotMessage(shooterAddr *net.UDPAddr, data string) {
+	shot, err := parser.ParseShotMessage(data)
+	if err != nil {
+		logger.warn("Unable to parse shot from packet (%s): %s", data, err)
+		return
+	}
+	addr := r.playerManager.HandlePlayerShot(shot, shooterAddr)
+	if addr != nil {
+		r.playerManager.NotifyRespawn(addr)
+		r.playerManager.BroadcastScores()
+	}
+}
+
+func (r *Room) handlePlayerFire(addr *net.UDPAddr, data string) {
+	// firing a weapon gives up spawn protection
+	r.playerManager.EndSpawnProtection(addr.String())
+
+	fire, err := parser.ParseFireMessage(data)
+	if err != nil {
+		logger.warn("Unable to parse fire from packet (%s): %s", data, err)
+		return
+	}
+	if weapon, ok := GetWeapon(fire.Weapon); !ok || !weapon.Projectile {
+		// hitscan hits are reported separately with H
+		return
+	}
+	if _, err := r.playerManager.FireProjectile(addr.String(), fire, time.Now().UnixMilli()); err != nil {
+		logger.warn(err.Error())
+	}
+}
+
+func (r *Room) handleReliableAck(addr *net.UDPAddr, data string) {
+	seq, err := strconv.Atoi(data)
+	if err != nil {
+		logger.warn("Unable to parse ack sequence from packet (%s)", data)
+		return
+	}
+	r.playerManager.AckReliable(addr, seq)
+}
+
+func (r *Room) handleSpectatorCamera(addr *net.UDPAddr, data string) {
+	mode, followID, err := parser.ParseCameraMessage(data)
+	if err != nil {
+		logger.warn("Unable to parse camera from packet (%s): %s", data, err)
+		return
+	}
+	if _, err := r.playerManager.SetSpectatorCamera(addr.String(), mode, followID); err != nil {
+		logger.warn(err.Error())
+	}
+}
+
+func (r *Room) handleChat(addr *net.UDPAddr, data string) {
+	message, err := parser.ParseChatMessage(data)
+	if err != nil {
+		logger.warn("Unable to parse chat from packet (%s): %s", data, err)
+		return
+	}
+	if err := r.playerManager.SendChat(addr.String(), message, time.Now().UnixMilli()); err != nil {
+		logger.warn(err.Error())
+	}
+}
+
+func (r *Room) handleChatMute(addr *net.UDPAddr, data string) {
+	playerID, muted, err := parser.ParseMuteMessage(data)
+	if err != nil {
+		logger.warn("Unable to parse mute from packet (%s): %s", data, err)
+		return
+	}
+	if err := r.playerManager.SetMuted(addr.String(), playerID, muted); err != nil {
+		logger.warn(err.Error())
+	}
+}
+
+func (r *Room) handleVote(addr *net.UDPAddr, data string) {
+	request, err := parser.ParseVoteMessage(data)
+	if err != nil {
+		logger.warn("Unable to parse vote from packet (%s): %s", data, err)
+		return
+	}
+	now := time.Now().UnixMilli()
+	if request.Type == "" {
+		err = r.playerManager.CastVote(addr.String(), request.Ballot, now)
+	} else {
+		err = r.playerManager.StartVote(addr.String(), request.Type, request.Arg, now)
+	}
+	if err != nil {
+		logger.warn(err.Error())
+	}
+}
+
+func (r *Room) handleLobby(addr *net.UDPAddr, data string) {
+	request, err := parser.ParseLobbyMessage(data)
+	if err != nil {
+		logger.warn("Unable to parse lobby request from packet (%s): %s", data, err)
+		return
+	}
+	if err := r.playerManager.HandleLobbyRequest(addr.String(), request); err != nil {
+		logger.warn(err.Error())
+	}
+}
+
+func (r *Room) handlePlayerLeave(addr *net.UDPAddr) {
+	if r.playerManager.LeaveJoinQueue(addr.String()) {
+		logger.info("Client %s left the join queue", addr.String())
+		return
+	}
+	// spectators leave quietly, players dont know they were there
+	if spectator, err := r.playerManager.RemoveSpectator(addr.String()); err == nil {
+		logger.info("Spectator %d left: %s", spectator.ID, spectator.Name)
+		return
+	}
+	playerState, err := r.playerManager.RemovePlayer(addr.String())
+	if err != nil {
+		logger.warn(err.Error())
+		return
+	}
+	r.broadcastPacket(r.playerManager.GetRecipientAddrs(), parser.EncodePlayerLeaveMessage(playerState.ID))
+	r.playerManager.BroadcastScores()
+	r.playerManager.RefreshLobby()
+
+	logger.info("Player %d left: %s", playerState.ID, playerState.Name)
+}
+
+// handlePlayerLogin reports whether the client is now in the room, logged in or waiting in its join queue
+func (r *Room) handlePlayerLogin(addr *net.UDPAddr, login Login) bool {
+	if login.Spectate {
+		return r.handleSpectatorLogin(addr, login)
+	}
+	newPlayerState, err := r.playerManager.CreatePlayer(addr, login.Name, login.Team)
+	if err != nil {
+		r.rejectLogin(addr, err)
+		return r.playerManager.IsQueued(addr.String())
+	}
+
+	r.playerManager.WelcomePlayer(newPlayerState)
+
+	logger.info("Player %d logged in: %s", newPlayerState.ID, newPlayerState.Name)
+	return true
+}
+
+// handleSpectatorLogin sends a new spectator everything a new player gets, minus a state of their own
+func (r *Room) handleSpectatorLogin(addr *net.UDPAddr, login Login) bool {
+	spectator, err := r.playerManager.CreateSpectator(addr, login.Name)
+	if err != nil {
+		r.rejectLogin(addr, err)
+		return false
+	}
+
+	playerStates := r.playerManager.GetAllPlayerStates(nil)
+	r.sendPacket(addr, parser.EncodeSpectatorInit(playerStates, r.playerManager.GetSnapshotEntities(), r.playerManager.GetChatHistory(NO_TEAM)))
+	r.sendPacket(addr, parser.EncodeSpectatorCamera(spectator))
+	match := r.playerManager.GetMatch()
+	r.sendPacket(addr, parser.EncodeMatchPhase(match.Phase(), match.RemainingMs(time.Now().UnixMilli())))
+	if pickups := r.playerManager.GetActivePickups(); len(pickups) > 0 {
+		r.sendPacket(addr, parser.EncodePickupSpawns(pickups))
+	}
+	r.sendPacket(addr, parser.EncodePlayerScores(playerStates))
+
+	logger.info("Spectator %d logged in: %s", spectator.ID, spectator.Name)
+	return true
+}
+
+// rejectLogin tells the client why its login was refused when it is something they can fix
+func (r *Room) rejectLogin(addr *net.UDPAddr, err error) {
+	logger.warn(err.Error())
+	var loginErr *LoginError
+	if errors.As(err, &loginErr) {
+		r.sendPacket(addr, parser.EncodeLoginRejected(loginErr))
+	}
+}
+
+func (r *Room) SetBroadcastDelay(newDelayMs int) {
+	// Lock to prevent race conditions while updating broadcastDelay
+	r.broadcastLock.Lock()
+	defer r.broadcastLock.Unlock()
+
+	r.quitBroadcast <- struct{}{}
+
+	// Stop the current ticker
+	r.broadcastTicker.Stop()
+
+	// Start a new ticker with the updated delay
+	r.broadcastTicker = time.NewTicker(time.Duration(newDelayMs) * time.Millisecond)
+	go r.broadcastPlayerStates()
+}
+
+// func (r *Room) calculateBroadcastDelay(playerStates []PlayerState) {
+// 	current_ms := time.Now().UnixMilli()
+// 	for _, ps := range playerStates {
+// 		latency := current_ms - ps.LastUpdatedAt
+
+// 		if latency > 50 {
+// 			logger.log(LOG_LEVEL_DEBUG, "[%v] Player %d: High latency %dms", time.Now().String(), ps.ID, latency)
+// 		}
+// 	}
+// }
@@ -0,0 +1,75 @@
+package udp_server
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// RoomDef is a room the server hosts from startup, read from the rooms file
+type RoomDef struct {
+	ID        string `json:"id"`
+	Mode      string `json:"mode"`      // one of the GAME_MODE_* constants, defaults to free for all
+	TeamCount int    `json:"teamCount"` // team modes default to 2 teams
+	Map       string `json:"map"`       // map file path, empty keeps the built in spawn area
+}
+
+// Config is the default config with the rooms mode and teams
+func (def RoomDef) Config() Config {
+	config := DefaultConfig()
+	if def.Mode != "" {
+		config.Mode = def.Mode
+	}
+	config.TeamCount = def.TeamCount
+	if config.Mode == GAME_MODE_TEAM_DEATHMATCH && config.TeamCount == 0 {
+		config.TeamCount = 2
+	}
+	return config
+}
+
+func LoadRoomDefs(path string) ([]RoomDef, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading rooms file %s: %s", path, err.Error())
+	}
+	var defs []RoomDef
+	if err := json.Unmarshal(data, &defs); err != nil {
+		return nil, fmt.Errorf("error parsing rooms file %s: %s", path, err.Error())
+	}
+	seen := map[string]bool{}
+	for _, def := range defs {
+		if seen[def.ID] {
+			return nil, fmt.Errorf("rooms file %s: room %s is defined twice", path, def.ID)
+		}
+		seen[def.ID] = true
+		if err := def.Config().Validate(); err != nil {
+			return nil, fmt.Errorf("rooms file %s: room %s: %s", path, def.ID, err.Error())
+		}
+	}
+	return defs, nil
+}
+
+// LoadRooms sets up every room in the rooms file, an entry for DEFAULT_ROOM_ID changes the default room
+func (s *server) LoadRooms(path string) error {
+	defs, err := LoadRoomDefs(path)
+	if err != nil {
+		return err
+	}
+	for _, def := range defs {
+		if def.ID != DEFAULT_ROOM_ID {
+			if _, err := s.CreateRoom(def.ID, def.Config(), def.Map); err != nil {
+				return fmt.Errorf("room %s: %s", def.ID, err.Error())
+			}
+			continue
+		}
+		if err := s.SetConfig(def.Config()); err != nil {
+			return fmt.Errorf("room %s: %s", def.ID, err.Error())
+		}
+		if def.Map != "" {
+			if err := s.LoadMap(def.Map); err != nil {
+				return fmt.Errorf("room %s: %s", def.ID, err.Error())
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package udp_server
+
+import (
+	"fmt"
+	"sync"
+)
+
+// RoomManager owns every room on the server and remembers which room each client logged into
+type RoomManager struct {
+	mu      sync.RWMutex
+	rooms   map[string]*Room
+	clients map[string]string // client address to room ID
+	started bool
+}
+
+func NewRoomManager() *RoomManager {
+	return &RoomManager{
+		rooms:   make(map[string]*Room),
+		clients: make(map[string]string),
+	}
+}
+
+// Add registers a room, its ticks start right away when the server is already running
+func (rm *RoomManager) Add(room *Room) error {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+	if _, ok := rm.rooms[room.ID]; ok {
+		return fmt.Errorf("room %s already exists", room.ID)
+	}
+	rm.rooms[room.ID] = room
+	if rm.started {
+		room.start()
+	}
+	return nil
+}
+
+func (rm *RoomManager) Get(id string) (*Room, bool) {
+	rm.mu.RLock()
+	defer rm.mu.RUnlock()
+	room, ok := rm.rooms[id]
+	return room, ok
+}
+
+// Remove stops a room and forgets the clients in it
+func (rm *RoomManager) Remove(id string) (*Room, bool) {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+	room, ok := rm.rooms[id]
+	if !ok {
+		return nil, false
+	}
+	delete(rm.rooms, id)
+	for addrStr, roomID := range rm.clients {
+		if roomID == id {
+			delete(rm.clients, addrStr)
+		}
+	}
+	if rm.started {
+		room.stop()
+	}
+	return room, true
+}
+
+// RoomOf returns the room a client is in
+func (rm *RoomManager) RoomOf(addrStr string) (*Room, bool) {
+	rm.mu.RLock()
+	defer rm.mu.RUnlock()
+	room, ok := rm.rooms[rm.clients[addrStr]]
+	return room, ok
+}
+
+func (rm *RoomManager) Assign(addrStr string, id string) {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+	rm.clients[addrStr] = id
+}
+
+func (rm *RoomManager) Unassign(addrStr string) {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+	delete(rm.clients, addrStr)
+}
+
+func (rm *RoomManager) StartAll() {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+	rm.started = true
+	for _, room := range rm.rooms {
+		room.start()
+	}
+}
+
+func (rm *RoomManager) StopAll() {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+	if !rm.started {
+		return
+	}
+	rm.started = false
+	for _, room := range rm.rooms {
+		room.stop()
+	}
+}
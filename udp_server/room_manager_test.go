@@ -0,0 +1,138 @@
+package udp_server
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newRoutingServer is a server without a socket whose packets are collected per client
+func newRoutingServer(t *testing.T) (*server, func(addr *net.UDPAddr) []string) {
+	var mu sync.Mutex
+	packets := map[string][]string{}
+	send := func(addr *net.UDPAddr, packet string) {
+		mu.Lock()
+		defer mu.Unlock()
+		packets[addr.String()] = append(packets[addr.String()], packet)
+	}
+	s := &server{rooms: NewRoomManager(), broadcastDelayMs: 1000, send: send}
+	config := newTestConfig()
+	for _, id := range []string{DEFAULT_ROOM_ID, "arena"} {
+		room := NewRoom(id, s.broadcastDelayMs, send)
+		assert.Nil(t, room.SetConfig(config))
+		assert.Nil(t, s.rooms.Add(room))
+	}
+	return s, func(addr *net.UDPAddr) []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return packets[addr.String()]
+	}
+}
+
+func TestLoginPicksRoom(t *testing.T) {
+	s, received := newRoutingServer(t)
+	s.processMessage(newTestAddr(1), []byte("L;first"))
+	s.processMessage(newTestAddr(2), []byte("L;second:0::arena"))
+
+	mainRoom, _ := s.rooms.Get(DEFAULT_ROOM_ID)
+	arena, _ := s.rooms.Get("arena")
+	assert.Len(t, mainRoom.playerManager.GetAllPlayerStates(nil), 1)
+	assert.Len(t, arena.playerManager.GetAllPlayerStates(nil), 1)
+	// players in other rooms are never announced
+	assert.False(t, hasPacket(received(newTestAddr(1)), "N;"))
+
+	s.processMessage(newTestAddr(3), []byte("L;third:0::nowhere"))
+	assert.Equal(t, []string{"D;room:nowhere"}, received(newTestAddr(3)))
+}
+
+func TestPacketsAreRoutedToTheClientsRoom(t *testing.T) {
+	s, received := newRoutingServer(t)
+	addr := newTestAddr(1)
+	s.processMessage(addr, []byte("L;player:0::arena"))
+	s.processMessage(newTestAddr(2), []byte("L;other"))
+	arena, _ := s.rooms.Get("arena")
+
+	s.processMessage(addr, []byte("G;all:0:hello arena"))
+	assert.True(t, hasReliablePacket(received(addr), "G;1:all:0:hello arena"))
+	assert.False(t, hasReliablePacket(received(newTestAddr(2)), "G;1:all:0:hello arena"))
+
+	// logging in somewhere else while playing is refused
+	s.processMessage(addr, []byte("L;player"))
+	mainRoom, _ := s.rooms.Get(DEFAULT_ROOM_ID)
+	assert.Len(t, mainRoom.playerManager.GetAllPlayerStates(nil), 1)
+
+	s.processMessage(addr, []byte("Q;"))
+	assert.Empty(t, arena.playerManager.GetAllPlayerStates(nil))
+	_, ok := s.rooms.RoomOf(addr.String())
+	assert.False(t, ok)
+}
+
+func TestRejectedLoginIsNotAssigned(t *testing.T) {
+	s, received := newRoutingServer(t)
+	addr := newTestAddr(1)
+	s.processMessage(addr, []byte("L;x"))
+
+	assert.True(t, hasPacket(received(addr), "D;name_length:"))
+	_, ok := s.rooms.RoomOf(addr.String())
+	assert.False(t, ok)
+}
+
+func TestSwitchingRoomsLeavesTheJoinQueue(t *testing.T) {
+	s, _ := newRoutingServer(t)
+	mainRoom, _ := s.rooms.Get(DEFAULT_ROOM_ID)
+	assert.Nil(t, mainRoom.SetConfig(newTestConfig(func(config *Config) {
+		config.MaxPlayers = 1
+	})))
+	first, waiting := newTestAddr(1), newTestAddr(2)
+
+	s.processMessage(first, []byte("L;first"))
+	s.processMessage(waiting, []byte("L;waiting"))
+	assert.True(t, mainRoom.playerManager.IsQueued(waiting.String()))
+
+	s.processMessage(waiting, []byte("L;waiting:0::arena"))
+	assert.False(t, mainRoom.playerManager.IsQueued(waiting.String()))
+	room, _ := s.rooms.RoomOf(waiting.String())
+	assert.Equal(t, "arena", room.ID)
+
+	// the freed slot in main doesnt pull the client back in
+	s.processMessage(first, []byte("Q;"))
+	mainRoom.playerManager.tickJoinQueue(time.Now().UnixMilli())
+	assert.Empty(t, mainRoom.playerManager.GetAllPlayerStates(nil))
+}
+
+func TestRemoveRoom(t *testing.T) {
+	s, received := newRoutingServer(t)
+	addr := newTestAddr(1)
+	s.processMessage(addr, []byte("L;player:0::arena"))
+
+	assert.Nil(t, s.RemoveRoom("arena"))
+	assert.True(t, hasPacket(received(addr), "D;room:arena"))
+	_, ok := s.rooms.RoomOf(addr.String())
+	assert.False(t, ok)
+	assert.NotNil(t, s.RemoveRoom(DEFAULT_ROOM_ID))
+	assert.NotNil(t, s.RemoveRoom("arena"))
+}
+
+func TestLoadRoomsFromFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rooms.json")
+	rooms := `[{"id": "main", "mode": "tdm"}, {"id": "duel", "map": "../maps/default.json"}]`
+	assert.Nil(t, os.WriteFile(path, []byte(rooms), 0o644))
+	s := NewServer(0, 1000)
+
+	assert.Nil(t, s.LoadRooms(path))
+	mainRoom, _ := s.rooms.Get(DEFAULT_ROOM_ID)
+	assert.Equal(t, GAME_MODE_TEAM_DEATHMATCH, mainRoom.playerManager.config.Mode)
+	assert.Equal(t, 2, mainRoom.playerManager.config.TeamCount)
+	duel, ok := s.rooms.Get("duel")
+	assert.True(t, ok)
+	assert.Equal(t, GAME_MODE_FREE_FOR_ALL, duel.playerManager.config.Mode)
+
+	bad := `[{"id": "arena", "mode": "ctf"}]`
+	assert.Nil(t, os.WriteFile(path, []byte(bad), 0o644))
+	assert.NotNil(t, s.LoadRooms(path))
+}
@@ -0,0 +1,96 @@
+package udp_server
+
+import (
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// SpawnPoint is a single spawn location, or a box shaped spawn zone when Extent is non zero
+type SpawnPoint struct {
+	Name     string   `json:"name"`
+	Position Position `json:"position"`
+	Extent   Position `json:"extent"` // half size of the zone along each axis
+}
+
+type SpawnManager struct {
+	mu         sync.Mutex
+	points     []SpawnPoint
+	lastUsedAt []int64
+	geometry   *MapGeometry
+}
+
+func NewSpawnManager(points []SpawnPoint, geometry *MapGeometry) *SpawnManager {
+	return &SpawnManager{
+		points:     points,
+		lastUsedAt: make([]int64, len(points)),
+		geometry:   geometry,
+	}
+}
+
+func (sp SpawnPoint) RandomPosition() Position {
+	return Position{
+		x: sp.Position.x + (rand.Float32()*2-1)*sp.Extent.x,
+		y: sp.Position.y + (rand.Float32()*2-1)*sp.Extent.y,
+		z: sp.Position.z + (rand.Float32()*2-1)*sp.Extent.z,
+	}
+}
+
+// PickSpawnPosition picks the spawn point furthest from the given enemies,
+// skipping points used in the last SPAWN_REUSE_COOLDOWN_MS unless every point was used recently
+func (sm *SpawnManager) PickSpawnPosition(enemyPositions []Position) Position {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	now := time.Now().UnixMilli()
+	best := sm.pickBest(enemyPositions, now, true)
+	if best < 0 {
+		best = sm.pickBest(enemyPositions, now, false)
+	}
+	sm.lastUsedAt[best] = now
+	return sm.clearPosition(sm.points[best])
+}
+
+// RandomPosition is a clear spot at any spawn point, used to give bots somewhere to walk to
+func (sm *SpawnManager) RandomPosition() Position {
+	return sm.clearPosition(sm.points[rand.Intn(len(sm.points))])
+}
+
+// clearPosition picks a spot in the zone where the player doesnt end up inside a wall,
+// falling back to the zone center which LoadMapData checked is clear
+func (sm *SpawnManager) clearPosition(sp SpawnPoint) Position {
+	for i := 0; i < SPAWN_POSITION_ATTEMPTS; i++ {
+		pos := sp.RandomPosition()
+		if !sm.geometry.Overlaps(playerBoxAt(pos, 0)) {
+			return pos
+		}
+	}
+	return sp.Position
+}
+
+func (sm *SpawnManager) pickBest(enemyPositions []Position, now int64, skipRecent bool) int {
+	best := -1
+	bestScore := -1.0
+	ties := 0
+	for i, sp := range sm.points {
+		if skipRecent && now-sm.lastUsedAt[i] < SPAWN_REUSE_COOLDOWN_MS {
+			continue
+		}
+		score := math.MaxFloat64
+		for _, enemyPos := range enemyPositions {
+			score = math.Min(score, sp.Position.DistanceTo(enemyPos))
+		}
+		switch {
+		case score > bestScore:
+			best, bestScore, ties = i, score, 1
+		case score == bestScore:
+			// pick uniformly among equally good points
+			ties++
+			if rand.Intn(ties) == 0 {
+				best = i
+			}
+		}
+	}
+	return best
+}
@@ -0,0 +1,44 @@
+package udp_server
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPickSpawnPositionAvoidsEnemies(t *testing.T) {
+	near := SpawnPoint{Name: "near", Position: Position{x: 0, y: 0, z: 0}}
+	far := SpawnPoint{Name: "far", Position: Position{x: 100, y: 0, z: 0}}
+	sm := NewSpawnManager([]SpawnPoint{near, far}, NewMapGeometry(nil))
+
+	enemies := []Position{{x: 1, y: 0, z: 0}}
+	assert.Equal(t, far.Position, sm.PickSpawnPosition(enemies))
+}
+
+func TestPickSpawnPositionSkipsRecentlyUsed(t *testing.T) {
+	near := SpawnPoint{Name: "near", Position: Position{x: 0, y: 0, z: 0}}
+	far := SpawnPoint{Name: "far", Position: Position{x: 100, y: 0, z: 0}}
+	sm := NewSpawnManager([]SpawnPoint{near, far}, NewMapGeometry(nil))
+
+	enemies := []Position{{x: 1, y: 0, z: 0}}
+	assert.Equal(t, far.Position, sm.PickSpawnPosition(enemies))
+	assert.Equal(t, near.Position, sm.PickSpawnPosition(enemies))
+	// every point is on cooldown, fall back to the best one
+	assert.Equal(t, far.Position, sm.PickSpawnPosition(enemies))
+}
+
+func TestSpawnZoneStaysInsideExtent(t *testing.T) {
+	zone := SpawnPoint{Position: Position{x: 5, y: 10, z: 5}, Extent: Position{x: 5, y: 0, z: 5}}
+	for i := 0; i < 100; i++ {
+		pos := zone.RandomPosition()
+		assert.InDelta(t, 5, pos.x, 5)
+		assert.Equal(t, float32(10), pos.y)
+		assert.InDelta(t, 5, pos.z, 5)
+	}
+}
+
+func TestLoadMapData(t *testing.T) {
+	mapData, err := LoadMapData("../" + DEFAULT_MAP_PATH)
+	assert.Nil(t, err)
+	assert.NotEmpty(t, mapData.SpawnPoints)
+}
@@ -0,0 +1,165 @@
+package udp_server
+
+import (
+	"fmt"
+	"net"
+)
+
+// Spectator is the component of a client that watches the match without a player of its own
+type Spectator struct {
+	ID       int
+	Addr     *net.UDPAddr
+	Name     string
+	Mode     string // one of the CAMERA_MODE_* constants
+	FollowID int    // the followed player, 0 in free camera
+}
+
+func (s Spectator) ComponentType() string {
+	return COMPONENT_SPECTATOR
+}
+
+func spectatorEntityKey(addrStr string) string {
+	return ENTITY_TYPE_SPECTATOR + ":" + addrStr
+}
+
+// CreateSpectator logs a client in as a spectator, it starts out following the first player if there is one
+func (pm *PlayerManager) CreateSpectator(addr *net.UDPAddr, name string) (Spectator, error) {
+	pm.joinMu.Lock()
+	defer pm.joinMu.Unlock()
+
+	if pm.isLoggedIn(addr.String()) {
+		return Spectator{}, fmt.Errorf("client %s: Cant login more than once", addr.String())
+	}
+	if pm.votes.IsBanned(addr, name) {
+		return Spectator{}, &LoginError{Reason: LOGIN_REJECT_BANNED}
+	}
+
+	name, err := pm.resolveName(name)
+	if err != nil {
+		return Spectator{}, err
+	}
+
+	spectator := Spectator{ID: pm.entities.ReserveID(), Addr: addr, Name: name}
+	spectator.Mode, spectator.FollowID = pm.nextFollowTarget(0)
+	if _, err := pm.entities.Add(spectator.ID, ENTITY_TYPE_SPECTATOR, spectatorEntityKey(addr.String()), spectator); err != nil {
+		return Spectator{}, err
+	}
+	return spectator, nil
+}
+
+// isLoggedIn reports whether the address already has a player or a spectator
+func (pm *PlayerManager) isLoggedIn(addrStr string) bool {
+	if _, ok := pm.entities.GetByKey(playerEntityKey(addrStr)); ok {
+		return true
+	}
+	_, ok := pm.entities.GetByKey(spectatorEntityKey(addrStr))
+	return ok
+}
+
+func (pm *PlayerManager) RemoveSpectator(addrStr string) (Spectator, error) {
+	spectator, err := pm.GetSpectator(addrStr)
+	if err != nil {
+		return Spectator{}, err
+	}
+	pm.entities.Remove(spectator.ID)
+	pm.reliable.Forget(spectator.Addr)
+	return spectator, nil
+}
+
+func (pm *PlayerManager) GetSpectator(addrStr string) (Spectator, error) {
+	entity, ok := pm.entities.GetByKey(spectatorEntityKey(addrStr))
+	if !ok {
+		return Spectator{}, fmt.Errorf("client %s: No spectator exists on server", addrStr)
+	}
+	spectator, ok := GetComponent[Spectator](entity)
+	if !ok {
+		return Spectator{}, fmt.Errorf("entity %d: Unable to get spectator component", entity.ID)
+	}
+	return spectator, nil
+}
+
+func (pm *PlayerManager) GetSpectators() []Spectator {
+	spectators := []Spectator{}
+	for _, entity := range pm.entities.Query(ENTITY_TYPE_SPECTATOR) {
+		if spectator, ok := GetComponent[Spectator](entity); ok {
+			spectators = append(spectators, spectator)
+		}
+	}
+	return spectators
+}
+
+// GetRecipientAddrs is everyone who receives game packets, players and spectators
+func (pm *PlayerManager) GetRecipientAddrs() []*net.UDPAddr {
+	addrs := []*net.UDPAddr{}
+	for _, ps := range pm.GetAllPlayerStates(nil) {
+		addrs = append(addrs, ps.Addr)
+	}
+	for _, spectator := range pm.GetSpectators() {
+		addrs = append(addrs, spectator.Addr)
+	}
+	return addrs
+}
+
+// SetSpectatorCamera switches a spectator between free camera and following a player,
+// CAMERA_NEXT follows the player after the current one. The new camera is sent back to the spectator
+func (pm *PlayerManager) SetSpectatorCamera(addrStr string, mode string, followID int) (Spectator, error) {
+	current, err := pm.GetSpectator(addrStr)
+	if err != nil {
+		return Spectator{}, err
+	}
+	switch mode {
+	case CAMERA_NEXT:
+		mode, followID = pm.nextFollowTarget(current.FollowID)
+	case CAMERA_MODE_FOLLOW:
+		if _, err := pm.GetPlayerStateByID(followID); err != nil {
+			return Spectator{}, err
+		}
+	case CAMERA_MODE_FREE:
+		followID = 0
+	default:
+		return Spectator{}, fmt.Errorf("unknown camera mode %s", mode)
+	}
+	return pm.moveCamera(current, mode, followID)
+}
+
+func (pm *PlayerManager) moveCamera(spectator Spectator, mode string, followID int) (Spectator, error) {
+	var updated Spectator
+	_, err := pm.entities.Modify(spectator.ID, func(e *Entity) {
+		updated, _ = GetComponent[Spectator](*e)
+		updated.Mode = mode
+		updated.FollowID = followID
+		e.SetComponent(updated)
+	})
+	if err != nil {
+		return Spectator{}, fmt.Errorf("client %s: %s", spectator.Addr.String(), err.Error())
+	}
+	pm.send(updated.Addr, parser.EncodeSpectatorCamera(updated))
+	return updated, nil
+}
+
+// nextFollowTarget is the player with the lowest ID above afterID, wrapping around, free camera when nobody is playing
+func (pm *PlayerManager) nextFollowTarget(afterID int) (string, int) {
+	players := pm.GetAllPlayerStates(nil)
+	if len(players) == 0 {
+		return CAMERA_MODE_FREE, 0
+	}
+	for _, ps := range players {
+		if ps.ID > afterID {
+			return CAMERA_MODE_FOLLOW, ps.ID
+		}
+	}
+	return CAMERA_MODE_FOLLOW, players[0].ID
+}
+
+// retargetSpectators moves everyone following a player who left on to the next player
+func (pm *PlayerManager) retargetSpectators(leftID int) {
+	for _, spectator := range pm.GetSpectators() {
+		if spectator.Mode != CAMERA_MODE_FOLLOW || spectator.FollowID != leftID {
+			continue
+		}
+		mode, followID := pm.nextFollowTarget(leftID)
+		if _, err := pm.moveCamera(spectator, mode, followID); err != nil {
+			logger.warn(err.Error())
+		}
+	}
+}
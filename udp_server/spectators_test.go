@@ -0,0 +1,74 @@
+package udp_server
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSpectatorIsNotAPlayer(t *testing.T) {
+	pm, packets := newMatchPlayerManager(t)
+	player, _ := pm.CreatePlayer(newTestAddr(1), "player", NO_TEAM)
+	spectator, err := pm.CreateSpectator(newTestAddr(2), "watcher")
+	assert.Nil(t, err)
+	assert.Equal(t, CAMERA_MODE_FOLLOW, spectator.Mode)
+	assert.Equal(t, player.ID, spectator.FollowID)
+
+	assert.Len(t, pm.GetAllPlayerStates(nil), 1)
+	assert.Empty(t, pm.GetSnapshotEntities())
+	_, err = pm.GetPlayerStateByID(spectator.ID)
+	assert.NotNil(t, err)
+
+	// spectators still get every event
+	pm.BroadcastScores()
+	assert.Len(t, *packets, 2)
+	assert.Len(t, pm.GetRecipientAddrs(), 2)
+}
+
+func TestSpectatorCantLoginTwice(t *testing.T) {
+	pm, _ := newMatchPlayerManager(t)
+	pm.CreateSpectator(newTestAddr(1), "watcher")
+
+	_, err := pm.CreateSpectator(newTestAddr(1), "watcher")
+	assert.NotNil(t, err)
+	_, err = pm.CreatePlayer(newTestAddr(1), "watcher", NO_TEAM)
+	assert.NotNil(t, err)
+}
+
+func TestSpectatorCamera(t *testing.T) {
+	pm, packets := newMatchPlayerManager(t)
+	first, _ := pm.CreatePlayer(newTestAddr(1), "first", NO_TEAM)
+	second, _ := pm.CreatePlayer(newTestAddr(2), "second", NO_TEAM)
+	spectator, _ := pm.CreateSpectator(newTestAddr(3), "watcher")
+	addrStr := spectator.Addr.String()
+
+	spectator, err := pm.SetSpectatorCamera(addrStr, CAMERA_NEXT, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, second.ID, spectator.FollowID)
+	spectator, _ = pm.SetSpectatorCamera(addrStr, CAMERA_NEXT, 0)
+	assert.Equal(t, first.ID, spectator.FollowID)
+
+	spectator, err = pm.SetSpectatorCamera(addrStr, CAMERA_MODE_FREE, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, CAMERA_MODE_FREE, spectator.Mode)
+	assert.True(t, hasPacket(*packets, "O;free:0"))
+
+	_, err = pm.SetSpectatorCamera(addrStr, CAMERA_MODE_FOLLOW, 99)
+	assert.NotNil(t, err)
+}
+
+func TestSpectatorFollowsNextPlayerWhenTargetLeaves(t *testing.T) {
+	pm, _ := newMatchPlayerManager(t)
+	first, _ := pm.CreatePlayer(newTestAddr(1), "first", NO_TEAM)
+	second, _ := pm.CreatePlayer(newTestAddr(2), "second", NO_TEAM)
+	spectator, _ := pm.CreateSpectator(newTestAddr(3), "watcher")
+	assert.Equal(t, first.ID, spectator.FollowID)
+
+	pm.RemovePlayer(first.Addr.String())
+	spectator, _ = pm.GetSpectator(spectator.Addr.String())
+	assert.Equal(t, second.ID, spectator.FollowID)
+
+	pm.RemovePlayer(second.Addr.String())
+	spectator, _ = pm.GetSpectator(spectator.Addr.String())
+	assert.Equal(t, CAMERA_MODE_FREE, spectator.Mode)
+}
@@ -0,0 +1,73 @@
+package udp_server
+
+// TeamDeathmatch scores kills for the killers team, team kills score nothing
+type TeamDeathmatch struct{}
+
+func (m *TeamDeathmatch) Name() string {
+	return GAME_MODE_TEAM_DEATHMATCH
+}
+
+func (m *TeamDeathmatch) OnJoin(pm *PlayerManager, ps *PlayerState, requestedTeam int) {
+	ps.Team = m.assignTeam(pm, requestedTeam)
+}
+
+func (m *TeamDeathmatch) OnLeave(pm *PlayerManager, ps PlayerState) {}
+
+func (m *TeamDeathmatch) OnDamage(pm *PlayerManager, victim PlayerState, attacker PlayerState, damage int) int {
+	if !attacker.IsEnemy(victim) && attacker.ID != victim.ID && !pm.config.FriendlyFire {
+		logger.debug("Player %d shot teammate %d, friendly fire is off", attacker.ID, victim.ID)
+		return 0
+	}
+	return damage
+}
+
+func (m *TeamDeathmatch) OnDeath(pm *PlayerManager, victim PlayerState, killer PlayerState, lastUpdatedAt int64) {
+	pm.RespawnPlayer(victim.Addr.String(), lastUpdatedAt)
+	if !killer.IsEnemy(victim) {
+		// no points for team kills
+		return
+	}
+	pm.AddPlayerScore(killer.Addr.String(), 1)
+	pm.AddTeamScore(killer.Team, 1)
+}
+
+func (m *TeamDeathmatch) OnBonusScore(pm *PlayerManager, ps PlayerState, score int) {
+	pm.AddPlayerScore(ps.Addr.String(), score)
+	pm.AddTeamScore(ps.Team, score)
+}
+
+func (m *TeamDeathmatch) OnTick(pm *PlayerManager, now int64) {}
+
+func (m *TeamDeathmatch) CheckWinCondition(pm *PlayerManager, timeUp bool) (int, bool) {
+	leader := TeamScore{Team: NO_TEAM, Score: -1}
+	for _, ts := range pm.GetTeamScores() {
+		if ts.Score > leader.Score {
+			leader = ts
+		}
+	}
+	if timeUp || (pm.config.ScoreLimit > 0 && leader.Score >= pm.config.ScoreLimit) {
+		return leader.Team, true
+	}
+	return 0, false
+}
+
+// assignTeam honours a valid requested team, otherwise picks the smallest team
+func (m *TeamDeathmatch) assignTeam(pm *PlayerManager, requestedTeam int) int {
+	teamCount := pm.config.TeamCount
+	if requestedTeam >= 1 && requestedTeam <= teamCount {
+		return requestedTeam
+	}
+	teamSizes := make([]int, teamCount+1)
+	for _, ps := range pm.GetAllPlayerStates(nil) {
+		if ps.Team >= 1 && ps.Team <= teamCount {
+			teamSizes[ps.Team]++
+		}
+	}
+	smallestTeam := 1
+	for team := 2; team <= teamCount; team++ {
+		if teamSizes[team] < teamSizes[smallestTeam] {
+			smallestTeam = team
+		}
+	}
+	return smallestTeam
+}
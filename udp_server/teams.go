@@ -0,0 +1,42 @@
+package udp_server
+
+import (
+	"fmt"
+)
+
+type TeamScore struct {
+	Team  int
+	Score int
+}
+
+func (ts TeamScore) String() string {
+	return fmt.Sprintf("%d:%d", ts.Team, ts.Score)
+}
+
+// IsEnemy reports whether other is fair game for ps, players without a team are enemies of everyone
+func (ps *PlayerState) IsEnemy(other PlayerState) bool {
+	if ps.ID == other.ID {
+		return false
+	}
+	return ps.Team == NO_TEAM || ps.Team != other.Team
+}
+
+func (pm *PlayerManager) AddTeamScore(team int, delta int) {
+	if team == NO_TEAM {
+		return
+	}
+	pm.teamScoresMu.Lock()
+	defer pm.teamScoresMu.Unlock()
+	pm.teamScores[team] += delta
+}
+
+func (pm *PlayerManager) GetTeamScores() []TeamScore {
+	pm.teamScoresMu.Lock()
+	defer pm.teamScoresMu.Unlock()
+
+	scores := []TeamScore{}
+	for team := 1; team <= pm.config.TeamCount; team++ {
+		scores = append(scores, TeamScore{Team: team, Score: pm.teamScores[team]})
+	}
+	return scores
+}
@@ -3,33 +3,84 @@ package udp_server
 import (
 	"fmt"
 	"net"
-	"strconv"
-	"strings"
-	"sync"
-	"time"
+	"regexp"
 )
 
+// room IDs come from clients and show up in logs, so only plain IDs are allowed
+var roomIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
 type server struct {
-	conn            *net.UDPConn
-	port            int
-	quitCh          chan struct{}
-	quitReceive     chan struct{}
-	quitBroadcast   chan struct{}
-	broadcastTicker *time.Ticker
-	broadcastLock   sync.Mutex
-	playerManager   *PlayerManager
+	conn             *net.UDPConn
+	port             int
+	quitCh           chan struct{}
+	quitReceive      chan struct{}
+	broadcastDelayMs int
+	rooms            *RoomManager
+	send             func(addr *net.UDPAddr, packet string) // every room sends through this
 }
 
+// NewServer creates a server with just the default room, which clients join when their login names no room
 func NewServer(port int, broadcastDelayMs int) *server {
-	return &server{
-		port:            port,
-		playerManager:   NewPlayerManager(),
-		broadcastTicker: time.NewTicker(time.Duration(broadcastDelayMs) * time.Millisecond),
-		quitCh:          make(chan struct{}),
-		quitReceive:     make(chan struct{}),
-		quitBroadcast:   make(chan struct{}),
+	s := &server{
+		port:             port,
+		broadcastDelayMs: broadcastDelayMs,
+		rooms:            NewRoomManager(),
+		quitCh:           make(chan struct{}),
+		quitReceive:      make(chan struct{}),
 	}
+	s.send = s.sendPacket
+	s.rooms.Add(NewRoom(DEFAULT_ROOM_ID, broadcastDelayMs, s.send))
+	return s
+}
+
+func (s *server) defaultRoom() *Room {
+	room, _ := s.rooms.Get(DEFAULT_ROOM_ID)
+	return room
+}
+
+// SetConfig sets the rules of the default room
+func (s *server) SetConfig(config Config) error {
+	return s.defaultRoom().SetConfig(config)
+}
+
+// LoadMap loads the map of the default room
+func (s *server) LoadMap(path string) error {
+	return s.defaultRoom().LoadMap(path)
+}
+
+// CreateRoom adds a room with its own rules and map, an empty map path keeps the built in spawn area
+func (s *server) CreateRoom(id string, config Config, mapPath string) (*Room, error) {
+	if !roomIDPattern.MatchString(id) {
+		return nil, fmt.Errorf("invalid room ID %s", id)
+	}
+	room := NewRoom(id, s.broadcastDelayMs, s.send)
+	if err := room.SetConfig(config); err != nil {
+		return nil, err
+	}
+	if mapPath != "" {
+		if err := room.LoadMap(mapPath); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.rooms.Add(room); err != nil {
+		return nil, err
+	}
+	logger.info("Room %s created", id)
+	return room, nil
+}
 
+// RemoveRoom closes a room, everyone still in it is told the room is gone
+func (s *server) RemoveRoom(id string) error {
+	if id == DEFAULT_ROOM_ID {
+		return fmt.Errorf("the default room cant be removed")
+	}
+	room, ok := s.rooms.Remove(id)
+	if !ok {
+		return fmt.Errorf("room %s doesnt exist", id)
+	}
+	room.broadcastPacket(room.playerManager.GetRecipientAddrs(), parser.EncodeLoginRejected(&LoginError{Reason: LOGIN_REJECT_ROOM, Detail: id}))
+	logger.info("Room %s removed", id)
+	return nil
 }
 
 func (s *server) Start() error {
@@ -49,15 +100,14 @@ func (s *server) Start() error {
 	s.conn = conn
 
 	go s.receiveMessages()
-	go s.broadcastPlayerStates()
+	s.rooms.StartAll()
 
 	// Wait for server to be stopped
 	<-s.quitCh
 
-	// Signal both goroutines to stop
+	// Signal all goroutines to stop
 	close(s.quitReceive)
-	close(s.quitBroadcast)
-	s.broadcastTicker.Stop()
+	s.rooms.StopAll()
 
 	return nil
 }
@@ -81,142 +131,60 @@ func (s *server) receiveMessages() {
 	}
 }
 
-func (s *server) broadcastPlayerStates() {
-	for {
-		select {
-		case <-s.quitBroadcast:
-			return
-		case <-s.broadcastTicker.C:
-			// logger.log(LOG_LEVEL_DEBUG, "BROADCAST TICK")
-			if playerStates := s.playerManager.GetAllPlayerStates(nil); len(playerStates) > 1 {
-				// go s.calculateBroadcastDelay(playerStates)
-
-				broadcastPacket := parser.EncodePlayerStatesForBroadcast(playerStates)
-				playerAddrs := []*net.UDPAddr{}
-				for _, ps := range playerStates {
-					playerAddrs = append(playerAddrs, ps.Addr)
-				}
-				s.broadcastPacket(playerAddrs, broadcastPacket)
-			}
-		}
-	}
-}
-
+// processMessage routes a packet to the room of the client, logins pick the room themselves
 func (s *server) processMessage(addr *net.UDPAddr, data []byte) {
 	msg, err := parser.ParseMessage(data)
 	if err != nil {
 		logger.warn("Unable to parse packet (%s): %s", data, err)
 		return
 	}
-	switch msg.messageType {
-	case PLAYER_SHOT_MESSAGE:
-		s.handlePlayerShotMessage(addr, msg.data)
-	case PLAYER_STATE_MESSAGE:
-		s.handlePlayerStateUpdate(addr, msg.data)
-	case PLAYER_LOGIN_MESSAGE:
+	if msg.messageType == PLAYER_LOGIN_MESSAGE {
 		s.handlePlayerLogin(addr, msg.data)
-	default:
-		logger.warn("Unknown message type: %s", data)
+		return
 	}
-}
-
-func (s *server) handlePlayerStateUpdate(addr *net.UDPAddr, data string) {
-	ps, err := parser.ParsePlayerState(data)
-	if err != nil {
-		logger.warn("Unable to parse player state from packet (%s): %s", data, err)
+	room, ok := s.rooms.RoomOf(addr.String())
+	if !ok {
+		logger.warn("Client %s is not in any room: %s", addr.String(), data)
 		return
 	}
-	addrStr := addr.String()
-	err = s.playerManager.UpdatePlayerState(addrStr, ps)
-	if err != nil {
-		logger.warn(err.Error())
+	room.processMessage(addr, msg)
+	if msg.messageType == PLAYER_LEAVE_MESSAGE {
+		s.rooms.Unassign(addr.String())
 	}
 }
 
-func (s *server) handlePlayerShotMessage(shooterAddr *net.UDPAddr, data string) {
-	chunks := strings.Split(data, ":")
-
-	hitPlayerID, err := strconv.Atoi(chunks[0])
-	if err != nil {
-		logger.warn("Unable to parse Player ID from packet (%s)", data)
+func (s *server) handlePlayerLogin(addr *net.UDPAddr, data string) {
+	login := parser.ParseLoginMessage(data)
+	if login.Room == "" {
+		login.Room = DEFAULT_ROOM_ID
 	}
-	lastUpdatedAt, err := strconv.ParseInt(chunks[1], 10, 64)
-	if err != nil {
-		logger.warn("Unable to parse timestamp from packet: %s", data)
+	room, ok := s.rooms.Get(login.Room)
+	if !ok {
+		logger.warn("Client %s tried to join missing room %s", addr.String(), login.Room)
+		s.send(addr, parser.EncodeLoginRejected(&LoginError{Reason: LOGIN_REJECT_ROOM, Detail: login.Room}))
+		return
 	}
-	addr := s.playerManager.HandlePlayerShot(hitPlayerID, shooterAddr, lastUpdatedAt)
-	if addr != nil {
-		s.sendPacket(addr, parser.EncodePlayerResetMessage())
-
-		playerAddrs := []*net.UDPAddr{}
-		playerStates := s.playerManager.GetAllPlayerStates(nil)
-		for _, ps := range playerStates {
-			playerAddrs = append(playerAddrs, ps.Addr)
+	// a client is only ever in one room, waiting in another rooms join queue is given up for the new room
+	if current, ok := s.rooms.RoomOf(addr.String()); ok && current.ID != room.ID {
+		if !current.playerManager.LeaveJoinQueue(addr.String()) && current.playerManager.isLoggedIn(addr.String()) {
+			logger.warn("client %s: Cant login to room %s while in room %s", addr.String(), room.ID, current.ID)
+			return
 		}
-		s.broadcastPacket(playerAddrs, parser.EncodePlayerScores(playerStates))
+		s.rooms.Unassign(addr.String())
 	}
-}
-
-func (s *server) sendPacket(addr *net.UDPAddr, packet string) {
-	s.conn.WriteToUDP([]byte(packet), addr)
-}
-
-func (s *server) broadcastPacket(addrs []*net.UDPAddr, packet string) {
-	for _, addr := range addrs {
-		s.sendPacket(addr, packet)
+	if room.handlePlayerLogin(addr, login) {
+		s.rooms.Assign(addr.String(), room.ID)
 	}
 }
 
-func (s *server) handlePlayerLogin(addr *net.UDPAddr, data string) {
-	name := parser.ParseLoginMessage(data)
-	newPlayerState, err := s.playerManager.CreatePlayer(addr, name)
-	if err != nil {
-		logger.warn(err.Error())
+func (s *server) sendPacket(addr *net.UDPAddr, packet string) {
+	if isBotAddr(addr) {
 		return
 	}
-
-	// Send all logged in players
-	existingPlayerStates := s.playerManager.GetAllPlayerStates(newPlayerState.Addr)
-	initPacket := parser.EncodePlayerStatesForInit(newPlayerState, existingPlayerStates)
-
-	// logger.log(LOG_LEVEL_DEBUG, "Player %d: Init packet (%s)", newPlayerState.ID, initPacket)
-	s.sendPacket(newPlayerState.Addr, initPacket)
-
-	existingPlayerAddrs := []*net.UDPAddr{}
-	for _, ps := range existingPlayerStates {
-		existingPlayerAddrs = append(existingPlayerAddrs, ps.Addr)
-	}
-	// broadcast to all players that new player is here
-	packet := parser.EncodePlayerStateForInit(newPlayerState)
-
-	// logger.log(LOG_LEVEL_DEBUG, "Player %d: Broadcast packet (%s)", newPlayerState.ID, packet)
-	s.broadcastPacket(existingPlayerAddrs, packet)
-
-	logger.info("Player %d logged in: %s", newPlayerState.ID, newPlayerState.Name)
+	s.conn.WriteToUDP([]byte(packet), addr)
 }
 
+// SetBroadcastDelay changes how often the default room sends S
 func (s *server) SetBroadcastDelay(newDelayMs int) {
-	// Lock to prevent race conditions while updating broadcastDelay
-	s.broadcastLock.Lock()
-	defer s.broadcastLock.Unlock()
-
-	s.quitBroadcast <- struct{}{}
-
-	// Stop the current ticker
-	s.broadcastTicker.Stop()
-
-	// Start a new ticker with the updated delay
-	s.broadcastTicker = time.NewTicker(time.Duration(newDelayMs) * time.Millisecond)
-	go s.broadcastPlayerStates()
+	s.defaultRoom().SetBroadcastDelay(newDelayMs)
 }
-
-// func (s *server) calculateBroadcastDelay(playerStates []PlayerState) {
-// 	current_ms := time.Now().UnixMilli()
-// 	for _, ps := range playerStates {
-// 		latency := current_ms - ps.LastUpdatedAt
-
-// 		if latency > 50 {
-// 			logger.log(LOG_LEVEL_DEBUG, "[%v] Player %d: High latency %dms", time.Now().String(), ps.ID, latency)
-// 		}
-// 	}
-// }
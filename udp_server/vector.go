@@ -0,0 +1,37 @@
+package udp_server
+
+import "math"
+
+func (p Position) Add(other Position) Position {
+	return Position{x: p.x + other.x, y: p.y + other.y, z: p.z + other.z}
+}
+
+func (p Position) Sub(other Position) Position {
+	return Position{x: p.x - other.x, y: p.y - other.y, z: p.z - other.z}
+}
+
+func (p Position) Scale(factor float32) Position {
+	return Position{x: p.x * factor, y: p.y * factor, z: p.z * factor}
+}
+
+func (p Position) Length() float64 {
+	return math.Sqrt(float64(p.x*p.x + p.y*p.y + p.z*p.z))
+}
+
+// Normalized returns a unit vector, or the zero vector if p has no length
+func (p Position) Normalized() Position {
+	length := p.Length()
+	if length == 0 {
+		return Position{}
+	}
+	return p.Scale(float32(1 / length))
+}
+
+func (p Position) IsFinite() bool {
+	for _, v := range []float32{p.x, p.y, p.z} {
+		if math.IsNaN(float64(v)) || math.IsInf(float64(v), 0) {
+			return false
+		}
+	}
+	return true
+}
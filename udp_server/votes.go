@@ -0,0 +1,257 @@
+package udp_server
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// map names are used as file names, so only plain names are allowed
+var mapNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+type Vote struct {
+	Type      string // one of the VOTE_TYPE_* constants
+	Arg       string
+	StarterID int
+	EndsAt    int64
+	Ballots   map[int]bool // player ID to yes or no
+	targetID  int          // player to kick
+	mapData   MapData      // map to change to, loaded when the vote starts so a broken map never gets voted in
+}
+
+type VoteStatus struct {
+	Type        string
+	Arg         string
+	State       string // one of the VOTE_STATE_* constants
+	Yes         int
+	No          int
+	Needed      int
+	RemainingMs int64
+}
+
+func (vs VoteStatus) String() string {
+	return fmt.Sprintf("%s:%s:%s:%d:%d:%d:%d", vs.Type, vs.Arg, vs.State, vs.Yes, vs.No, vs.Needed, vs.RemainingMs)
+}
+
+// VoteManager runs one vote at a time and remembers who was kicked until the match ends
+type VoteManager struct {
+	mu          sync.Mutex
+	current     *Vote
+	lastStarted map[int]int64   // player ID to when they last started a vote
+	bannedAddrs map[string]bool // addresses of kicked players
+	bannedNames map[string]bool // lower cased names of kicked players
+}
+
+func NewVoteManager() *VoteManager {
+	return &VoteManager{
+		lastStarted: make(map[int]int64),
+		bannedAddrs: make(map[string]bool),
+		bannedNames: make(map[string]bool),
+	}
+}
+
+// Ban keeps out the kicked client and anyone logging in with its name, other players behind the same IP can stay
+func (vm *VoteManager) Ban(addr *net.UDPAddr, name string) {
+	vm.mu.Lock()
+	defer vm.mu.Unlock()
+	vm.bannedAddrs[addr.String()] = true
+	vm.bannedNames[strings.ToLower(name)] = true
+}
+
+func (vm *VoteManager) IsBanned(addr *net.UDPAddr, name string) bool {
+	vm.mu.Lock()
+	defer vm.mu.Unlock()
+	return vm.bannedAddrs[addr.String()] || vm.bannedNames[strings.ToLower(name)]
+}
+
+// ClearBans lifts every kick, called when a new match starts
+func (vm *VoteManager) ClearBans() {
+	vm.mu.Lock()
+	defer vm.mu.Unlock()
+	vm.bannedAddrs = make(map[string]bool)
+	vm.bannedNames = make(map[string]bool)
+}
+
+// voters are the human players who get a say, the player a kick vote is about doesnt
+func (pm *PlayerManager) voters(vote *Vote) []PlayerState {
+	voters := []PlayerState{}
+	for _, ps := range pm.GetAllPlayerStates(nil) {
+		if isBotAddr(ps.Addr) || (vote.Type == VOTE_TYPE_KICK && ps.ID == vote.targetID) {
+			continue
+		}
+		voters = append(voters, ps)
+	}
+	return voters
+}
+
+// voteStatus counts the ballots of players still in the game, vm.mu must be held
+func (pm *PlayerManager) voteStatus(vote *Vote, now int64) VoteStatus {
+	voters := pm.voters(vote)
+	status := VoteStatus{Type: vote.Type, Arg: vote.Arg, State: VOTE_STATE_OPEN}
+	for _, ps := range voters {
+		if yes, ok := vote.Ballots[ps.ID]; ok && yes {
+			status.Yes++
+		} else if ok {
+			status.No++
+		}
+	}
+	status.Needed = int(math.Floor(pm.config.VotePassRatio*float64(len(voters)))) + 1
+	if vote.Type == VOTE_TYPE_KICK && status.Needed < VOTE_KICK_MIN_YES {
+		// the starter alone never gets to kick someone
+		status.Needed = VOTE_KICK_MIN_YES
+	}
+	if status.RemainingMs = vote.EndsAt - now; status.RemainingMs < 0 {
+		status.RemainingMs = 0
+	}
+	targetLeft := false
+	if vote.Type == VOTE_TYPE_KICK {
+		_, err := pm.GetPlayerStateByID(vote.targetID)
+		targetLeft = err != nil
+	}
+	switch {
+	case targetLeft:
+		status.State = VOTE_STATE_FAILED
+	case status.Yes >= status.Needed:
+		status.State = VOTE_STATE_PASSED
+	case len(voters)-status.No < status.Needed || now >= vote.EndsAt:
+		// time is up or not enough players are left who could still say yes
+		status.State = VOTE_STATE_FAILED
+	}
+	return status
+}
+
+// StartVote opens a vote for the player at addrStr, who votes yes straight away
+func (pm *PlayerManager) StartVote(addrStr string, voteType string, arg string, now int64) error {
+	starter, err := pm.GetPlayerState(addrStr)
+	if err != nil {
+		return err
+	}
+	vote := &Vote{Type: voteType, Arg: arg, StarterID: starter.ID, EndsAt: now + pm.config.VoteTimeoutMs, Ballots: map[int]bool{starter.ID: true}}
+	switch voteType {
+	case VOTE_TYPE_KICK:
+		if vote.targetID, err = strconv.Atoi(arg); err != nil {
+			return fmt.Errorf("unable to parse player ID to kick: %s", err.Error())
+		}
+		if _, err := pm.GetPlayerStateByID(vote.targetID); err != nil || vote.targetID == starter.ID {
+			return fmt.Errorf("Player %d cant vote to kick player %d", starter.ID, vote.targetID)
+		}
+	case VOTE_TYPE_MAP:
+		if !mapNamePattern.MatchString(arg) {
+			return fmt.Errorf("invalid map name %s", arg)
+		}
+		if vote.mapData, err = LoadMapData(filepath.Join(pm.config.MapDir, arg+".json")); err != nil {
+			return err
+		}
+	case VOTE_TYPE_RESTART:
+		vote.Arg = ""
+	default:
+		return fmt.Errorf("unknown vote type %s", voteType)
+	}
+
+	pm.votes.mu.Lock()
+	if pm.votes.current != nil {
+		pm.votes.mu.Unlock()
+		return fmt.Errorf("Player %d cant start a vote while another is running", starter.ID)
+	}
+	if lastStarted, ok := pm.votes.lastStarted[starter.ID]; ok && now-lastStarted < pm.config.VoteCooldownMs {
+		pm.votes.mu.Unlock()
+		return fmt.Errorf("Player %d has to wait before starting another vote", starter.ID)
+	}
+	pm.votes.current = vote
+	pm.votes.lastStarted[starter.ID] = now
+	status := pm.voteStatus(vote, now)
+	pm.votes.mu.Unlock()
+
+	logger.info("Player %d started a %s vote %s", starter.ID, voteType, vote.Arg)
+	pm.broadcastReliable(parser.EncodeVoteStatus(status))
+	return nil
+}
+
+// CastVote records a players vote, changing an earlier one is allowed. The result is applied on the next tick
+func (pm *PlayerManager) CastVote(addrStr string, yes bool, now int64) error {
+	voter, err := pm.GetPlayerState(addrStr)
+	if err != nil {
+		return err
+	}
+	pm.votes.mu.Lock()
+	vote := pm.votes.current
+	if vote == nil {
+		pm.votes.mu.Unlock()
+		return fmt.Errorf("Player %d voted with no vote running", voter.ID)
+	}
+	if vote.Type == VOTE_TYPE_KICK && vote.targetID == voter.ID {
+		pm.votes.mu.Unlock()
+		return fmt.Errorf("Player %d cant vote on their own kick", voter.ID)
+	}
+	vote.Ballots[voter.ID] = yes
+	status := pm.voteStatus(vote, now)
+	pm.votes.mu.Unlock()
+
+	pm.broadcastReliable(parser.EncodeVoteStatus(status))
+	return nil
+}
+
+// tickVotes closes the running vote once it is decided and carries it out if it passed
+func (pm *PlayerManager) tickVotes(now int64) {
+	pm.votes.mu.Lock()
+	vote := pm.votes.current
+	if vote == nil {
+		pm.votes.mu.Unlock()
+		return
+	}
+	status := pm.voteStatus(vote, now)
+	if status.State == VOTE_STATE_OPEN {
+		pm.votes.mu.Unlock()
+		return
+	}
+	pm.votes.current = nil
+	pm.votes.mu.Unlock()
+
+	logger.info("Vote %s %s %s with %d yes and %d no", vote.Type, vote.Arg, status.State, status.Yes, status.No)
+	pm.broadcastReliable(parser.EncodeVoteStatus(status))
+	if status.State == VOTE_STATE_PASSED {
+		pm.carryOutVote(vote, now)
+	}
+}
+
+func (pm *PlayerManager) carryOutVote(vote *Vote, now int64) {
+	switch vote.Type {
+	case VOTE_TYPE_KICK:
+		pm.KickPlayer(vote.targetID)
+	case VOTE_TYPE_MAP:
+		pm.SetMapData(vote.mapData)
+		pm.RestartMatch(now)
+		if pickups := pm.GetActivePickups(); len(pickups) > 0 {
+			pm.broadcast(parser.EncodePickupSpawns(pickups))
+		}
+	case VOTE_TYPE_RESTART:
+		pm.RestartMatch(now)
+	}
+}
+
+// KickPlayer removes a player and keeps their address and name out until the match ends, bots are just removed
+func (pm *PlayerManager) KickPlayer(playerID int) {
+	target, err := pm.GetPlayerStateByID(playerID)
+	if err != nil {
+		logger.warn(err.Error())
+		return
+	}
+	if isBotAddr(target.Addr) {
+		pm.RemoveBot(playerID)
+		return
+	}
+	pm.votes.Ban(target.Addr, target.Name)
+	pm.send(target.Addr, parser.EncodeLoginRejected(&LoginError{Reason: LOGIN_REJECT_BANNED}))
+	if _, err := pm.RemovePlayer(target.Addr.String()); err != nil {
+		logger.warn(err.Error())
+		return
+	}
+	pm.broadcast(parser.EncodePlayerLeaveMessage(target.ID))
+	pm.BroadcastScores()
+	logger.info("Player %d was kicked: %s", target.ID, target.Name)
+}
@@ -0,0 +1,129 @@
+package udp_server
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKickVoteBansUntilMatchEnds(t *testing.T) {
+	pm, packets := newMatchPlayerManager(t)
+	first, _ := pm.CreatePlayer(newTestAddr(1), "first", NO_TEAM)
+	second, _ := pm.CreatePlayer(newTestAddr(2), "second", NO_TEAM)
+	griefer, _ := pm.CreatePlayer(&net.UDPAddr{IP: net.IPv4(10, 0, 0, 9), Port: 1}, "griefer", NO_TEAM)
+	now := time.Now().UnixMilli()
+
+	assert.Nil(t, pm.StartVote(first.Addr.String(), VOTE_TYPE_KICK, "3", now))
+	assert.True(t, hasReliablePacket(*packets, "V;kick:3:open:1:0:2:30000"))
+	assert.NotNil(t, pm.CastVote(griefer.Addr.String(), false, now))
+	pm.tickVotes(now)
+	assert.Len(t, pm.GetAllPlayerStates(nil), 3)
+
+	assert.Nil(t, pm.CastVote(second.Addr.String(), true, now))
+	pm.tickVotes(now)
+	assert.Len(t, pm.GetAllPlayerStates(nil), 2)
+	assert.True(t, hasReliablePacket(*packets, "V;kick:3:passed:2:0:2:30000"))
+	assert.True(t, hasPacket(*packets, "D;banned:"))
+
+	// the ban is on the address and the name, until the next match
+	_, err := pm.CreatePlayer(griefer.Addr, "someone", NO_TEAM)
+	assert.IsType(t, &LoginError{}, err)
+	_, err = pm.CreatePlayer(&net.UDPAddr{IP: net.IPv4(10, 0, 0, 9), Port: 2}, "GRIEFER", NO_TEAM)
+	assert.IsType(t, &LoginError{}, err)
+	// someone else behind the same IP can still play
+	_, err = pm.CreatePlayer(&net.UDPAddr{IP: net.IPv4(10, 0, 0, 9), Port: 3}, "flatmate", NO_TEAM)
+	assert.Nil(t, err)
+	pm.RestartMatch(now)
+	_, err = pm.CreatePlayer(&net.UDPAddr{IP: net.IPv4(10, 0, 0, 9), Port: 2}, "griefer", NO_TEAM)
+	assert.Nil(t, err)
+}
+
+func TestKickNeedsMoreThanTheStarter(t *testing.T) {
+	pm, packets := newMatchPlayerManager(t)
+	first, _ := pm.CreatePlayer(newTestAddr(1), "first", NO_TEAM)
+	pm.CreatePlayer(newTestAddr(2), "second", NO_TEAM)
+	now := time.Now().UnixMilli()
+
+	assert.Nil(t, pm.StartVote(first.Addr.String(), VOTE_TYPE_KICK, "2", now))
+	pm.tickVotes(now)
+	assert.True(t, hasReliablePacket(*packets, "V;kick:2:failed:1:0:2:30000"))
+	assert.Len(t, pm.GetAllPlayerStates(nil), 2)
+}
+
+func TestVoteFailsOnTimeoutOrTooManyNoVotes(t *testing.T) {
+	pm, packets := newMatchPlayerManager(t)
+	first, _ := pm.CreatePlayer(newTestAddr(1), "first", NO_TEAM)
+	second, _ := pm.CreatePlayer(newTestAddr(2), "second", NO_TEAM)
+	third, _ := pm.CreatePlayer(newTestAddr(3), "third", NO_TEAM)
+	now := time.Now().UnixMilli()
+
+	assert.Nil(t, pm.StartVote(first.Addr.String(), VOTE_TYPE_RESTART, "", now))
+	assert.NotNil(t, pm.StartVote(second.Addr.String(), VOTE_TYPE_RESTART, "", now))
+	pm.tickVotes(now + pm.config.VoteTimeoutMs)
+	assert.True(t, hasReliablePacket(*packets, "V;restart::failed:1:0:2:0"))
+
+	assert.Nil(t, pm.StartVote(second.Addr.String(), VOTE_TYPE_RESTART, "", now))
+	assert.Nil(t, pm.CastVote(first.Addr.String(), false, now))
+	pm.tickVotes(now)
+	assert.NotNil(t, pm.votes.current)
+	// 2 of 3 needed, a second no leaves only one who could say yes
+	assert.Nil(t, pm.CastVote(third.Addr.String(), false, now))
+	pm.tickVotes(now)
+	assert.Nil(t, pm.votes.current)
+}
+
+func TestVoteCooldownAndValidation(t *testing.T) {
+	pm, _ := newMatchPlayerManager(t)
+	first, _ := pm.CreatePlayer(newTestAddr(1), "first", NO_TEAM)
+	pm.CreatePlayer(newTestAddr(2), "second", NO_TEAM)
+	addrStr := first.Addr.String()
+	now := time.Now().UnixMilli()
+
+	assert.NotNil(t, pm.StartVote(addrStr, VOTE_TYPE_KICK, "1", now))
+	assert.NotNil(t, pm.StartVote(addrStr, VOTE_TYPE_KICK, "99", now))
+	assert.NotNil(t, pm.StartVote(addrStr, VOTE_TYPE_MAP, "../secrets", now))
+	assert.NotNil(t, pm.StartVote(addrStr, "surrender", "", now))
+
+	assert.Nil(t, pm.StartVote(addrStr, VOTE_TYPE_RESTART, "", now))
+	pm.tickVotes(now + pm.config.VoteTimeoutMs)
+	assert.NotNil(t, pm.StartVote(addrStr, VOTE_TYPE_RESTART, "", now+pm.config.VoteTimeoutMs))
+	assert.Nil(t, pm.StartVote(addrStr, VOTE_TYPE_RESTART, "", now+pm.config.VoteCooldownMs))
+}
+
+func TestMapVoteChangesMapAndRestarts(t *testing.T) {
+	pm, packets := newMatchPlayerManager(t, func(config *Config) {
+		config.MapDir = "../maps"
+	})
+	player, _ := pm.CreatePlayer(newTestAddr(1), "player", NO_TEAM)
+	pm.AddPlayerScore(player.Addr.String(), 3)
+	now := time.Now().UnixMilli()
+
+	assert.Nil(t, pm.StartVote(player.Addr.String(), VOTE_TYPE_MAP, "default", now))
+	pm.tickVotes(now)
+	assert.NotNil(t, pm.currentLevel().world.bounds)
+	assert.True(t, hasPacket(*packets, "M;warmup:-1"))
+	player, _ = pm.GetPlayerState(player.Addr.String())
+	assert.Equal(t, 0, player.Score)
+}
+
+func TestMapChangeWhilePlayersMove(t *testing.T) {
+	pm, _ := newMatchPlayerManager(t)
+	player, _ := pm.CreatePlayer(newTestAddr(1), "player", NO_TEAM)
+	mapData, err := LoadMapData("../maps/default.json")
+	assert.Nil(t, err)
+
+	// run with -race, the map swap happens on the tick while packets keep moving players
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 20; i++ {
+			pm.SetMapData(mapData)
+		}
+		close(done)
+	}()
+	for i := 0; i < 20; i++ {
+		placePlayer(pm, player, Position{x: float32(i % 5)})
+	}
+	<-done
+}
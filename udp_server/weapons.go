@@ -0,0 +1,63 @@
+package udp_server
+
+// WeaponDef describes a weapon, hitscan weapons are reported by clients with H messages and
+// projectile weapons are fired with F messages and simulated on the server
+type WeaponDef struct {
+	Name       string
+	Damage     int
+	Projectile bool
+	Speed      float32 // units per second
+	Gravity    float32 // units per second squared pulling the projectile down
+	LifetimeMs int64
+	// splash hits everyone but the direct hit within SplashRadius of the impact, falling off to nothing at the edge
+	SplashRadius float64
+	SplashDamage int
+	// scales Damage by the zone a hitscan shot landed in, missing zones count as 1
+	ZoneMultipliers map[string]float64
+}
+
+// ZoneDamage is the damage for a hit in the given zone, fractions are carried over to the next hit on the
+// same player by takeZoneDamage rather than rounded
+func (w WeaponDef) ZoneDamage(zone string) float64 {
+	multiplier, ok := w.ZoneMultipliers[zone]
+	if !ok {
+		return float64(w.Damage)
+	}
+	return float64(w.Damage) * multiplier
+}
+
+var weapons = map[string]WeaponDef{
+	WEAPON_RIFLE: {
+		Name:   WEAPON_RIFLE,
+		Damage: 1,
+		ZoneMultipliers: map[string]float64{
+			HIT_ZONE_HEAD: 3,
+			HIT_ZONE_BODY: 1,
+			HIT_ZONE_LIMB: 0.5,
+		},
+	},
+	WEAPON_ROCKET: {
+		Name:         WEAPON_ROCKET,
+		Damage:       3,
+		Projectile:   true,
+		Speed:        25,
+		LifetimeMs:   4 * 1000,
+		SplashRadius: 3,
+		SplashDamage: 2,
+	},
+	WEAPON_GRENADE: {
+		Name:         WEAPON_GRENADE,
+		Damage:       2,
+		Projectile:   true,
+		Speed:        12,
+		Gravity:      9.8,
+		LifetimeMs:   2500,
+		SplashRadius: 4,
+		SplashDamage: 3,
+	},
+}
+
+func GetWeapon(name string) (WeaponDef, bool) {
+	weapon, ok := weapons[name]
+	return weapon, ok
+}
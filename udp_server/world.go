@@ -0,0 +1,163 @@
+package udp_server
+
+import (
+	"fmt"
+	"net"
+)
+
+// HazardDef is a map volume that hurts anyone inside it, a kill plane is a hazard with enough damage to kill outright
+type HazardDef struct {
+	Name       string   `json:"name"`
+	Min        Position `json:"min"`
+	Max        Position `json:"max"`
+	Damage     int      `json:"damage"`
+	IntervalMs int64    `json:"intervalMs"` // time between damage ticks while the player stays inside
+}
+
+func (hd HazardDef) Volume() Box {
+	return Box{Name: hd.Name, Min: hd.Min, Max: hd.Max}
+}
+
+// World holds the map rules that apply to player positions, bounds are optional
+type World struct {
+	bounds       *Box
+	boundsAction string
+	hazards      []HazardDef
+}
+
+func NewWorld(mapData MapData) *World {
+	action := mapData.BoundsAction
+	if action == "" {
+		action = BOUNDS_ACTION_SNAP
+	}
+	return &World{
+		bounds:       mapData.Bounds,
+		boundsAction: action,
+		hazards:      mapData.Hazards,
+	}
+}
+
+func (w *World) InBounds(p Position) bool {
+	return p.IsFinite() && (w.bounds == nil || w.bounds.Contains(p))
+}
+
+// HazardAt returns the most damaging hazard the player box is in, stance doesnt matter to hazards
+func (w *World) HazardAt(feet Position) (HazardDef, bool) {
+	box := playerBoxAt(feet, 0)
+	var worst HazardDef
+	found := false
+	for _, hazard := range w.hazards {
+		if hazard.Volume().Intersects(box) && (!found || hazard.Damage > worst.Damage) {
+			worst, found = hazard, true
+		}
+	}
+	return worst, found
+}
+
+func validateWorld(mapData MapData) error {
+	if mapData.Bounds != nil {
+		if !mapData.Bounds.IsValid() {
+			return fmt.Errorf("bounds are not a valid box")
+		}
+		for _, sp := range mapData.SpawnPoints {
+			if !mapData.Bounds.Contains(sp.Position) {
+				return fmt.Errorf("spawn point %s is out of bounds", sp.Name)
+			}
+		}
+	}
+	switch mapData.BoundsAction {
+	case "", BOUNDS_ACTION_SNAP, BOUNDS_ACTION_KILL:
+	default:
+		return fmt.Errorf("unknown bounds action %s", mapData.BoundsAction)
+	}
+	for _, hazard := range mapData.Hazards {
+		if !hazard.Volume().IsValid() || hazard.Damage <= 0 || hazard.IntervalMs < 0 {
+			return fmt.Errorf("hazard %s needs a valid volume, positive damage and a non negative interval", hazard.Name)
+		}
+	}
+	return nil
+}
+
+// checkBounds handles a reported position outside the world, it returns false when the update must not be applied.
+// Positions that are not finite are always snapped back, there is nowhere sensible to kill them at
+func (pm *PlayerManager) checkBounds(oldState PlayerState, newPosition Position, now int64) bool {
+	world := pm.currentLevel().world
+	if world.InBounds(newPosition) {
+		return true
+	}
+	// once the match is decided nobody dies, they are snapped back like on maps without kill bounds
+	if newPosition.IsFinite() && world.boundsAction == BOUNDS_ACTION_KILL && pm.match.AcceptsDamage() {
+		logger.debug("Player %d left the world bounds", oldState.ID)
+		pm.killByEnvironment(oldState, WEAPON_OUT_OF_BOUNDS, now)
+		return false
+	}
+	logger.debug("Player %d reported an invalid position, snapping back", oldState.ID)
+	pm.send(oldState.Addr, parser.EncodePlayerResetMessage(oldState))
+	return false
+}
+
+func (pm *PlayerManager) tickHazards(now int64) {
+	if !pm.match.AcceptsDamage() {
+		return
+	}
+	world := pm.currentLevel().world
+	for _, ps := range pm.GetAllPlayerStates(nil) {
+		if ps.Health <= 0 || now-ps.RespawnAt < RESPAWN_IDLE_DELAY_MS {
+			continue
+		}
+		hazard, ok := world.HazardAt(ps.Position)
+		if !ok {
+			continue
+		}
+		damaged := false
+		updatedState, err := pm.modifyPlayerState(ps.Addr.String(), func(ps *PlayerState) {
+			if now-ps.LastHazardAt < hazard.IntervalMs {
+				return
+			}
+			// hazards go straight through armor
+			ps.Health -= hazard.Damage
+			ps.LastDamagedAt = now
+			ps.LastHazardAt = now
+			damaged = true
+		})
+		if err != nil || !damaged {
+			continue
+		}
+		if updatedState.Health <= 0 {
+			logger.debug("Player %d died in hazard %s", ps.ID, hazard.Name)
+			pm.killByEnvironment(updatedState, WEAPON_HAZARD, now)
+		}
+	}
+}
+
+// killByEnvironment kills a player for something the map did, the last player to hit them
+// recently gets the kill as if they had landed the shot, otherwise nobody does
+func (pm *PlayerManager) killByEnvironment(victim PlayerState, weapon string, now int64) {
+	shot := Shot{HitPlayerID: victim.ID, LastUpdatedAt: now, Weapon: weapon}
+	if attackerID, ok := pm.damageLedger.LastAttacker(victim.ID, now, ENVIRONMENT_CREDIT_WINDOW_MS); ok {
+		if attacker, err := pm.GetPlayerStateByID(attackerID); err == nil {
+			HandlePlayerDeath(victim, attacker, shot, pm)
+			pm.notifyEnvironmentDeath(victim.Addr)
+			return
+		}
+	}
+
+	logger.info("Player %d killed by the environment", victim.ID)
+	environment := PlayerState{ID: ENVIRONMENT_ID}
+	pm.awardAssists(victim, victim)
+	pm.endStreak(victim, environment)
+	// scored like a suicide, the victim respawns and nobody gets a point
+	pm.gameMode.OnDeath(pm, victim, victim, now)
+	pm.broadcastReliable(parser.EncodeKillEvent(KillEvent{
+		KillerID: ENVIRONMENT_ID,
+		VictimID: victim.ID,
+		Weapon:   weapon,
+		Flags:    KILL_FLAG_ENVIRONMENT,
+	}))
+	pm.notifyEnvironmentDeath(victim.Addr)
+}
+
+func (pm *PlayerManager) notifyEnvironmentDeath(addr *net.UDPAddr) {
+	pm.NotifyRespawn(addr)
+	pm.BroadcastScores()
+}
@@ -0,0 +1,107 @@
+package udp_server
+
+import (
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInvalidPositionSnapsBack(t *testing.T) {
+	pm, packets := newMatchPlayerManager(t)
+	pm.currentLevel().world = NewWorld(MapData{Bounds: &Box{Min: Position{x: -10, y: -10, z: -10}, Max: Position{x: 10, y: 10, z: 10}}})
+	player, _ := pm.CreatePlayer(newTestAddr(1), "player", NO_TEAM)
+	placePlayer(pm, player, Position{x: 1})
+
+	nan := float32(math.NaN())
+	err := pm.UpdatePlayerState(player.Addr.String(), PlayerState{Position: Position{x: nan}, LastUpdatedAt: time.Now().UnixMilli()})
+	assert.NotNil(t, err)
+	err = pm.UpdatePlayerState(player.Addr.String(), PlayerState{Position: Position{x: 50}, LastUpdatedAt: time.Now().UnixMilli()})
+	assert.NotNil(t, err)
+
+	playerState, _ := pm.GetPlayerState(player.Addr.String())
+	assert.Equal(t, Position{x: 1}, playerState.Position)
+	assert.Equal(t, 0, playerState.Deaths)
+	assert.True(t, hasPacket(*packets, fmt.Sprintf("%s;1.000,0.000,0.000", PLAYER_RESET_MESSAGE)))
+}
+
+func TestOutOfBoundsKills(t *testing.T) {
+	pm, packets := newMatchPlayerManager(t)
+	pm.currentLevel().world = NewWorld(MapData{
+		Bounds:       &Box{Min: Position{x: -10, y: -10, z: -10}, Max: Position{x: 10, y: 10, z: 10}},
+		BoundsAction: BOUNDS_ACTION_KILL,
+	})
+	player, _ := pm.CreatePlayer(newTestAddr(1), "player", NO_TEAM)
+	placePlayer(pm, player, Position{y: -50})
+
+	playerState, _ := pm.GetPlayerState(player.Addr.String())
+	assert.Equal(t, 1, playerState.Deaths)
+	assert.Equal(t, MAX_HEALTH, playerState.Health)
+	kill := fmt.Sprintf("%s;%d:%d:%s:%d", KILL_MESSAGE, ENVIRONMENT_ID, player.ID, WEAPON_OUT_OF_BOUNDS, KILL_FLAG_ENVIRONMENT)
+	assert.True(t, hasReliablePacket(*packets, kill))
+}
+
+func TestOutOfBoundsSnapsBackAfterTheMatch(t *testing.T) {
+	pm, _ := newMatchPlayerManager(t)
+	pm.currentLevel().world = NewWorld(MapData{
+		Bounds:       &Box{Min: Position{x: -10, y: -10, z: -10}, Max: Position{x: 10, y: 10, z: 10}},
+		BoundsAction: BOUNDS_ACTION_KILL,
+	})
+	player, _ := pm.CreatePlayer(newTestAddr(1), "player", NO_TEAM)
+	pm.CreatePlayer(newTestAddr(2), "other", NO_TEAM)
+	pm.Tick(0)
+	pm.Tick(1000)
+	pm.Tick(6000)
+	assert.Equal(t, MATCH_PHASE_POST_MATCH, pm.GetMatch().Phase())
+
+	before, _ := pm.GetPlayerState(player.Addr.String())
+	placePlayer(pm, player, Position{y: -50})
+	playerState, _ := pm.GetPlayerState(player.Addr.String())
+	assert.Equal(t, before.Deaths, playerState.Deaths)
+	assert.Equal(t, before.Position, playerState.Position)
+}
+
+func TestHazardDamageOverTime(t *testing.T) {
+	pm, _ := newMatchPlayerManager(t)
+	pm.currentLevel().world = NewWorld(MapData{Hazards: []HazardDef{
+		{Name: "lava", Min: Position{x: -5, y: -1, z: -5}, Max: Position{x: 5, y: 0.5, z: 5}, Damage: 1, IntervalMs: 1000},
+	}})
+	player, _ := pm.CreatePlayer(newTestAddr(1), "player", NO_TEAM)
+	placePlayer(pm, player, Position{})
+
+	now := time.Now().UnixMilli()
+	pm.tickHazards(now)
+	pm.tickHazards(now + 500)
+	playerState, _ := pm.GetPlayerState(player.Addr.String())
+	assert.Equal(t, MAX_HEALTH-1, playerState.Health)
+
+	pm.tickHazards(now + 1000)
+	playerState, _ = pm.GetPlayerState(player.Addr.String())
+	assert.Equal(t, MAX_HEALTH-2, playerState.Health)
+
+	// out of the lava
+	placePlayer(pm, player, Position{x: 20})
+	pm.tickHazards(now + 2000)
+	playerState, _ = pm.GetPlayerState(player.Addr.String())
+	assert.Equal(t, MAX_HEALTH-2, playerState.Health)
+}
+
+func TestHazardKillCreditsLastAttacker(t *testing.T) {
+	pm, packets := newMatchPlayerManager(t)
+	pm.currentLevel().world = NewWorld(MapData{Hazards: []HazardDef{
+		{Name: "kill plane", Min: Position{x: -50, y: -50, z: -50}, Max: Position{x: 50, y: -10, z: 50}, Damage: 100},
+	}})
+	shooter, _ := pm.CreatePlayer(newTestAddr(1), "shooter", NO_TEAM)
+	victim, _ := pm.CreatePlayer(newTestAddr(2), "victim", NO_TEAM)
+	shootPlayer(pm, shooter, victim)
+	placePlayer(pm, victim, Position{y: -20})
+
+	pm.tickHazards(time.Now().UnixMilli())
+	shooterState, _ := pm.GetPlayerState(shooter.Addr.String())
+	victimState, _ := pm.GetPlayerState(victim.Addr.String())
+	assert.Equal(t, 1, shooterState.Score)
+	assert.Equal(t, 1, victimState.Deaths)
+	assert.True(t, hasReliablePacket(*packets, fmt.Sprintf("%s;%d:%d:%s:0", KILL_MESSAGE, shooter.ID, victim.ID, WEAPON_HAZARD)))
+}